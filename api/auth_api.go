@@ -2,11 +2,17 @@
 package api
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"morshed/app/controllers"
+	"morshed/data/datasource"
+	"morshed/data/engine/cache"
 	"morshed/data/engine/sql"
-	"morshed/data/repositories"
 	middleware "morshed/domain/middlewares"
 	"morshed/domain/services"
 
@@ -18,9 +24,20 @@ import (
 )
 
 // Router accepts any required dependencies and returns the main server's handler.
-func Router(db sql.Database, secret string) func(iris.Party) {
+func Router(src *datasource.Source, secret string) func(iris.Party) {
 	return func(r iris.Party) {
-		r.Use(requestid.New())
+		r.Use(requestid.New(), middleware.RequestContext)
+		if len(datasource.MySQLReplicaHosts()) > 0 {
+			r.Use(middleware.ReadYourWrites(datasource.ReadYourWritesWindow()))
+		}
+
+		// The peers ask this process for the cached records it owns and its cache statistics,
+		// they are not clients so it is mounted before the token verification
+		// and the pool only serves the requests of the peers, see `cache.Pool.Authorized`.
+		if pool := src.CachePool(); pool != nil {
+			r.Get(pool.BasePath()+peerStatsPath, peersOnly(pool), writeLocalCacheStats(src))
+			r.Any(pool.BasePath()+"{key:path}", iris.FromStd(pool))
+		}
 
 		signer := jwt.NewSigner(jwt.HS256, secret, 15*time.Minute)
 		r.Get("/token", writeToken(signer))
@@ -34,10 +51,10 @@ func Router(db sql.Database, secret string) func(iris.Party) {
 		// access on create, update and delete endpoinds.
 
 		var (
-			userRepository = repositories.NewUserRepository(db)
+			userRepository = src.Users()
 			userService    = services.NewUserService(userRepository)
 
-			productRepository = repositories.NewProductRepository(db)
+			productRepository = src.Products()
 			productService    = services.NewProductService(productRepository)
 		)
 
@@ -61,7 +78,7 @@ func Router(db sql.Database, secret string) func(iris.Party) {
 		users := mvc.New(r.Party("/users"))
 		// Add the basic authentication(admin:password) middleware
 		// for the /users based requests.
-		users.Router.Use(middleware.BasicAuth)
+		users.Router.Use(middleware.BasicAuth, middleware.Conditional("users"))
 		// Bind the "userService" to the UserController's Service (interface) field.
 		users.Register(userService)
 		users.Handle(new(controllers.UsersController))
@@ -69,13 +86,137 @@ func Router(db sql.Database, secret string) func(iris.Party) {
 		/////////////////// Product /////////////////////
 
 		prod := mvc.New(r.Party("/product"))
+		prod.Router.Use(middleware.Conditional("products"))
 		prod.Register(
 			productService,
 		)
 		prod.Handle(new(controllers.ProductController))
+
+		/////////////////// Debug /////////////////////
+
+		// The statistics of the database queries, admins only.
+		debug := r.Party("/debug", middleware.BasicAuth)
+		debug.Get("/queries", writeQueryStats(src))
+		debug.Get("/cache", writeCacheStats(src))
+	}
+}
+
+// writeQueryStats sends the statistics of the database queries by their shape,
+// the most time consuming first, see `datasource.Source.QueryStats`.
+func writeQueryStats(src *datasource.Source) iris.Handler {
+	return func(ctx iris.Context) {
+		stats := src.QueryStats()
+		if stats == nil {
+			stats = []sql.QueryStats{}
+		}
+
+		ctx.JSON(stats)
+	}
+}
+
+// peerStatsPath is the path, under the base path of the cache pool,
+// the peers serve their local cache statistics on.
+const peerStatsPath = "_stats"
+
+// peersOnly forbids the requests which do not come from one of the peers of the "pool".
+func peersOnly(pool *cache.Pool) iris.Handler {
+	return func(ctx iris.Context) {
+		if !pool.Authorized(ctx.Request()) {
+			ctx.StopWithStatus(iris.StatusForbidden)
+			return
+		}
+
+		ctx.Next()
 	}
 }
 
+// writeLocalCacheStats sends the statistics of the caches of this process to a peer.
+func writeLocalCacheStats(src *datasource.Source) iris.Handler {
+	return func(ctx iris.Context) {
+		ctx.JSON(src.CacheReport())
+	}
+}
+
+// writeCacheStats sends the statistics of the caches of each peer, by the peer's base URL,
+// see `datasource.Source.CacheReport`. The peers are asked for their local statistics
+// as a peer, the credentials of the request are never sent to them.
+// A peer which fails to respond is reported with its error.
+func writeCacheStats(src *datasource.Source) iris.Handler {
+	return func(ctx iris.Context) {
+		local := src.CacheReport()
+		pool := src.CachePool()
+		if onlyLocal, _ := ctx.URLParamBool("local"); pool == nil || onlyLocal {
+			ctx.JSON(local)
+			return
+		}
+
+		type peerReport struct {
+			*datasource.CacheReport
+			Error string `json:"error,omitempty"`
+		}
+
+		var (
+			mu      sync.Mutex
+			wg      sync.WaitGroup
+			reports = map[string]peerReport{pool.Self(): {CacheReport: &local}}
+		)
+
+		reqCtx, cancel := context.WithTimeout(ctx.Request().Context(), 2*time.Second)
+		defer cancel()
+
+		for _, peer := range pool.Peers() {
+			if peer == pool.Self() {
+				continue
+			}
+
+			wg.Add(1)
+			go func(peer string) {
+				defer wg.Done()
+
+				report, err := fetchCacheReport(reqCtx, pool, peer)
+				r := peerReport{CacheReport: report}
+				if err != nil {
+					r.Error = err.Error()
+				}
+
+				mu.Lock()
+				reports[peer] = r
+				mu.Unlock()
+			}(peer)
+		}
+		wg.Wait()
+
+		ctx.JSON(reports)
+	}
+}
+
+// fetchCacheReport returns the local cache statistics of the "peer" of the "pool",
+// requested with the credentials of the pool, see `cache.Pool.Authorize`.
+func fetchCacheReport(ctx context.Context, pool *cache.Pool, peer string) (*datasource.CacheReport, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, peer+pool.BasePath()+peerStatsPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	pool.Authorize(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+
+	report := new(datasource.CacheReport)
+	if err = json.NewDecoder(resp.Body).Decode(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
 func writeToken(signer *jwt.Signer) iris.Handler {
 	return func(ctx iris.Context) {
 		claims := jwt.Claims{
@@ -1,12 +1,17 @@
 package controllers
 
 import (
+	"context"
+
 	"morshed/data/engine/sql"
 	"morshed/data/models"
+	middleware "morshed/domain/middlewares"
+	"morshed/domain/repositories"
 	"morshed/domain/services"
 	"morshed/helpers"
 
 	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
 )
 
 // ProductHandler is the http mux for products.
@@ -15,19 +20,64 @@ type ProductController struct {
 	Service services.ProductService
 }
 
-func (c *ProductController) Get() ([]models.Product, error) {
-	prods, err := c.Service.GetAll()
-	return prods, err
+// BeforeActivation registers the restore and purge routes.
+func (c *ProductController) BeforeActivation(b mvc.BeforeActivation) {
+	b.Handle(iris.MethodPost, "/{id:int64}/restore", "Restore")
+	b.Handle(iris.MethodDelete, "/{id:int64}/purge", "Purge")
+}
+
+// service returns the product service of the request,
+// admins can include the soft deleted products with ?include_deleted=true
+// and any client can fetch their category with ?include=category.
+func (c *ProductController) service() (services.ProductService, error) {
+	service := c.Service
+	include, err := includeDeleted(c.Ctx)
+	if err != nil {
+		return service, err
+	}
+	if include {
+		service = service.Unscoped()
+	}
+
+	relations, err := includeRelations(c.Ctx, new(models.Product))
+	if err != nil {
+		return service, err
+	}
+	if len(relations) > 0 {
+		service = service.Preload(relations...)
+	}
+
+	return service, nil
+}
+
+// Get sends a page of the products and the cursor of the next one,
+// filtered, sorted and paginated by the URL parameters, see `listOptions`.
+// Method: GET.
+func (c *ProductController) Get() (repositories.Page[models.Product], error) {
+	service, err := c.service()
+	if err != nil {
+		return repositories.Page[models.Product]{}, err
+	}
+
+	page, err := service.List(c.Ctx, listOptions(c.Ctx))
+	return page, listError(c.Ctx, err)
 }
 
 // GetByID fetches a single record from the database and sends it to the client.
 // Method: GET.
 func (c *ProductController) GetBy(id int64) (models.Product, error) {
-	prod, err := c.Service.GetByID(id)
+	service, err := c.service()
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	prod, err := service.GetByID(c.Ctx, id)
 	if err != nil {
 		// this message will be binded to the
 		// main.go -> app.OnAnyErrorCode -> NotFound -> shared/error.html -> .Message text.
 		c.Ctx.Values().Set("message", "Product couldn't be found!")
+	} else {
+		setValidators(c.Ctx, &prod)
 	}
 	return prod, err // it will throw/emit 404 if found == false.
 }
@@ -40,7 +90,7 @@ func (h *ProductController) Post() {
 		return
 	}
 
-	id, err := h.Service.Create(product)
+	prod, err := h.Service.Create(h.Ctx, product)
 	if err != nil {
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity, helpers.MnewError(iris.StatusUnprocessableEntity, h.Ctx.Request().Method, h.Ctx.Path(), "required fields are missing"))
@@ -52,9 +102,8 @@ func (h *ProductController) Post() {
 		return
 	}
 
-	// Send 201 with body of {"id":$last_inserted_id"}.
-	h.Ctx.StatusCode(iris.StatusCreated)
-	h.Ctx.JSON(iris.Map{product.PrimaryKey(): id})
+	// Send 201 with the stored product and its URL.
+	helpers.MwriteCreated(h.Ctx, prod.ID, prod)
 }
 
 // Update performs a full-update of a record in the database.
@@ -65,8 +114,25 @@ func (h *ProductController) Put() {
 		return
 	}
 
-	prod, err := h.Service.Update(product)
+	// the If-Match header, if any, is the version the client has read.
+	matched, err := matchVersion(h.Ctx, &product)
 	if err != nil {
+		h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity, helpers.MnewError(iris.StatusUnprocessableEntity, h.Ctx.Request().Method, h.Ctx.Path(), "invalid If-Match header"))
+		return
+	}
+
+	prod, err := h.Service.Update(h.Ctx, product)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.MwriteEntityNotFound(h.Ctx)
+			return
+		}
+
+		if err == sql.ErrConflict {
+			writeConflict(h.Ctx, matched)
+			return
+		}
+
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity, helpers.MnewError(iris.StatusUnprocessableEntity, h.Ctx.Request().Method, h.Ctx.Path(), "required fields are missing"))
 			return
@@ -80,6 +146,8 @@ func (h *ProductController) Put() {
 	status := iris.StatusOK
 	if prod.ID <= 0 {
 		status = iris.StatusNotModified
+	} else {
+		setETag(h.Ctx, &prod)
 	}
 
 	h.Ctx.StatusCode(status)
@@ -90,13 +158,19 @@ func (h *ProductController) Put() {
 func (h *ProductController) Patch() {
 	id := h.Ctx.Params().GetInt64Default("id", 0)
 
-	var attrs map[string]interface{}
+	attrs := make(map[string]interface{})
 	if err := h.Ctx.ReadJSON(&attrs); err != nil {
 		return
 	}
+	matched := matchAttrsVersion(h.Ctx, new(models.Product), attrs)
 
-	affected, err := h.Service.PatchUpdate(id, attrs)
+	affected, err := h.Service.PatchUpdate(h.Ctx, id, attrs)
 	if err != nil {
+		if err == sql.ErrConflict {
+			writeConflict(h.Ctx, matched)
+			return
+		}
+
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity,
 				helpers.MnewError(iris.StatusUnprocessableEntity,
@@ -122,7 +196,7 @@ func (h *ProductController) Patch() {
 func (h *ProductController) Delete() {
 	id := h.Ctx.Params().GetInt64Default("id", 0)
 
-	affected, err := h.Service.DeleteByID(id)
+	affected, err := h.Service.DeleteByID(h.Ctx, id)
 	if err != nil {
 		helpers.Mdebugf("ProductHandler.Delete(DB): %v", err)
 		helpers.MwriteInternalServerError(h.Ctx)
@@ -136,3 +210,41 @@ func (h *ProductController) Delete() {
 
 	h.Ctx.StatusCode(status)
 }
+
+// Restore brings back a soft deleted product, admins only.
+// Method: POST.
+func (h *ProductController) Restore(id int64) {
+	h.adminWrite("Restore", id, h.Service.Restore)
+}
+
+// Purge removes a product permanently, even if it is soft deleted, admins only.
+// Method: DELETE.
+func (h *ProductController) Purge(id int64) {
+	h.adminWrite("Purge", id, h.Service.Purge)
+}
+
+func (h *ProductController) adminWrite(name string, id int64, fn func(context.Context, int64) (int, error)) {
+	if !middleware.IsAdmin(h.Ctx) {
+		h.Ctx.StopWithJSON(iris.StatusForbidden, helpers.MnewError(iris.StatusForbidden, h.Ctx.Request().Method, h.Ctx.Path(), "admins only"))
+		return
+	}
+
+	affected, err := fn(h.Ctx, id)
+	if err != nil {
+		if err == sql.ErrUnprocessable {
+			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity, helpers.MnewError(iris.StatusUnprocessableEntity, h.Ctx.Request().Method, h.Ctx.Path(), "unsupported operation"))
+			return
+		}
+
+		helpers.Mdebugf("ProductHandler.%s(DB): %v", name, err)
+		helpers.MwriteInternalServerError(h.Ctx)
+		return
+	}
+
+	status := iris.StatusOK
+	if affected == 0 {
+		status = iris.StatusNotModified
+	}
+
+	h.Ctx.StatusCode(status)
+}
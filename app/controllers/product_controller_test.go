@@ -0,0 +1,229 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"morshed/data/engine/memory"
+	"morshed/data/models"
+	"morshed/data/repositories"
+	"morshed/domain/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+)
+
+// newProductApp returns an app which serves the "products" on /product from the memory engine.
+func newProductApp(t *testing.T, products ...models.Product) *iris.Application {
+	t.Helper()
+
+	service := services.NewProductService(repositories.NewMemoryRepository[models.Product](memory.New()))
+	for _, p := range products {
+		if _, err := service.Create(context.Background(), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	app := iris.New()
+	prod := mvc.New(app.Party("/product"))
+	prod.Register(service)
+	prod.Handle(new(ProductController))
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app
+}
+
+type productPage struct {
+	Items      []models.Product `json:"items"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+// getProducts sends a GET /product request with the "query" and returns the status code and the page.
+func getProducts(t *testing.T, app *iris.Application, query string) (int, productPage) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/product"+query, nil))
+
+	var page productPage
+	if rec.Code == iris.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: %v: %s", query, err, rec.Body)
+		}
+	}
+
+	return rec.Code, page
+}
+
+func productIDs(list []models.Product) []int64 {
+	ids := make([]int64, 0, len(list))
+	for _, p := range list {
+		ids = append(ids, p.ID)
+	}
+
+	return ids
+}
+
+func testProducts() []models.Product {
+	product := func(categoryID int64, title string, price float32) models.Product {
+		return models.Product{CategoryID: categoryID, Title: title, ImageURL: "/img.png", Price: price, Description: "-"}
+	}
+
+	return []models.Product{
+		product(1, "Cairo tower", 5),         // 1
+		product(2, "Nile cruise", 40),        // 2
+		product(3, "Giza pyramids", 10),      // 3
+		product(1, "Cairo museum", 20),       // 4
+		product(2, "Alexandria library", 10), // 5
+	}
+}
+
+func TestProductControllerListFilters(t *testing.T) {
+	app := newProductApp(t, testProducts()...)
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected []int64
+	}{
+		{"all", "?by=id", iris.StatusOK, []int64{1, 2, 3, 4, 5}},
+		{"greater or equal", "?price[gte]=10&by=id", iris.StatusOK, []int64{2, 3, 4, 5}},
+		{"in", "?category_id[in]=1,2&by=id", iris.StatusOK, []int64{1, 2, 4, 5}},
+		{"combined", "?price[gte]=10&category_id[in]=1,2&by=id", iris.StatusOK, []int64{2, 4, 5}},
+		{"any", "?price[lt]=10&category_id[eq]=3&match=any&by=id", iris.StatusOK, []int64{1, 3}},
+		{"like", "?title[like]=cairo&by=id", iris.StatusOK, []int64{1, 4}},
+		{"sorted", "?by=price&order=desc", iris.StatusOK, []int64{2, 4, 5, 3, 1}},
+		{"offset", "?by=id&offset=3", iris.StatusOK, []int64{4, 5}},
+		{"unknown column", "?secret[eq]=1", iris.StatusUnprocessableEntity, nil},
+		{"unknown sort column", "?by=secret", iris.StatusUnprocessableEntity, nil},
+		{"invalid cursor", "?cursor=nope", iris.StatusUnprocessableEntity, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, page := getProducts(t, app, tt.query)
+			if status != tt.status {
+				t.Fatalf("expected status %d but got %d", tt.status, status)
+			}
+
+			if got := productIDs(page.Items); tt.expected != nil && !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected products %v but got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProductControllerListCursor(t *testing.T) {
+	app := newProductApp(t, testProducts()...)
+
+	for _, tt := range []struct {
+		query    string
+		expected []int64
+	}{
+		{"?by=price&limit=2", []int64{1, 3, 5, 4, 2}},
+		{"?by=price&order=desc&limit=2", []int64{2, 4, 5, 3, 1}},
+		{"?price[gte]=10&by=id&limit=2", []int64{2, 3, 4, 5}},
+	} {
+		var (
+			ids    []int64
+			cursor string
+		)
+		for pages := 0; pages == 0 || cursor != ""; pages++ {
+			if pages > 5 {
+				t.Fatalf("%s: the pagination does not end", tt.query)
+			}
+
+			query := tt.query
+			if cursor != "" {
+				// the offset is ignored along with a cursor.
+				query += "&offset=1&cursor=" + cursor
+			}
+
+			status, page := getProducts(t, app, query)
+			if status != iris.StatusOK {
+				t.Fatalf("%s: expected status %d but got %d", query, iris.StatusOK, status)
+			}
+
+			ids = append(ids, productIDs(page.Items)...)
+			cursor = page.NextCursor
+		}
+
+		if !reflect.DeepEqual(ids, tt.expected) {
+			t.Fatalf("%s: expected products %v but got %v", tt.query, tt.expected, ids)
+		}
+	}
+}
+
+// putProduct sends a PUT /product request of the "product" with the "ifMatch" header, if not empty,
+// and returns the status code and the ETag response header.
+func putProduct(t *testing.T, app *iris.Application, product models.Product, ifMatch string) (int, string) {
+	t.Helper()
+
+	b, err := json.Marshal(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("PUT", "/product", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	return rec.Code, rec.Header().Get("ETag")
+}
+
+func TestProductControllerPutConflict(t *testing.T) {
+	app := newProductApp(t, testProducts()[:2]...)
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/product/1", nil))
+	etag := rec.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("expected the ETag of a new product to be %q but got %q", `"1"`, etag)
+	}
+
+	var product models.Product
+	if err := json.Unmarshal(rec.Body.Bytes(), &product); err != nil {
+		t.Fatal(err)
+	}
+	product.Title = "Cairo tower at night"
+	current := product
+	current.Version = 2
+
+	tests := []struct {
+		name    string
+		product models.Product
+		ifMatch string
+		status  int
+		etag    string
+	}{
+		{"read version", product, etag, iris.StatusOK, `"2"`},
+		{"changed since read, If-Match", product, etag, iris.StatusPreconditionFailed, ""},
+		{"changed since read, body version", product, "", iris.StatusConflict, ""},
+		{"current body version", current, "", iris.StatusOK, `"3"`},
+		{"missing", models.Product{ID: 99, CategoryID: 1, Title: "-", ImageURL: "-", Price: 1, Description: "-", Version: 1},
+			"", iris.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, etag := putProduct(t, app, tt.product, tt.ifMatch)
+			if status != tt.status {
+				t.Fatalf("expected status %d but got %d", tt.status, status)
+			}
+			if etag != tt.etag {
+				t.Fatalf("expected ETag %q but got %q", tt.etag, etag)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"morshed/data/engine/sql"
+	middleware "morshed/domain/middlewares"
+
+	"github.com/kataras/iris/v12"
+)
+
+// errAdminOnly is returned when a non-admin client asks for the soft deleted records.
+var errAdminOnly = errors.New("include_deleted is only available to admins")
+
+// errUnknownRelation is returned when a client asks for a relation the record does not have.
+var errUnknownRelation = errors.New("include refers to an unknown relation")
+
+// errInvalidList is returned when the parameters of a list refer to unknown columns or carry an invalid cursor.
+var errInvalidList = errors.New("invalid filter, sort or cursor parameters")
+
+// includeDeleted reports whether the request asks for the soft deleted records too,
+// through the ?include_deleted=true URL parameter. It is available only to admins,
+// other clients receive a 403 status code and `errAdminOnly`.
+func includeDeleted(ctx iris.Context) (bool, error) {
+	if include, _ := ctx.URLParamBool("include_deleted"); !include {
+		return false, nil
+	}
+
+	if !middleware.IsAdmin(ctx) {
+		ctx.StatusCode(iris.StatusForbidden)
+		return false, errAdminOnly
+	}
+
+	return true, nil
+}
+
+// includeRelations returns the relations of the "rec" record the request asks for
+// through the ?include= URL parameter, e.g. ?include=category or ?include=ratings.user.
+// Unknown relations result to a 400 status code and `errUnknownRelation`.
+func includeRelations(ctx iris.Context, rec sql.Record) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(ctx.URLParam("include"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	if err := sql.ValidatePreload(rec, names...); err != nil {
+		ctx.StatusCode(iris.StatusBadRequest)
+		return nil, errUnknownRelation
+	}
+
+	return names, nil
+}
+
+// listOptions returns the filters, the sort order and the pagination of a list request, e.g.
+// ?price[gte]=10&category_id[in]=1,2&by=price&order=desc&limit=20&cursor=..., see `sql.ParseListOptions`.
+// The relations of ?include= are preloaded by the services instead, see `includeRelations`.
+func listOptions(ctx iris.Context) sql.ListOptions {
+	opts := sql.ParseListOptions(ctx.Request().URL.Query())
+	opts.Include = nil
+	return opts
+}
+
+// listError returns the error of a list, invalid parameters result to a 422 status code and `errInvalidList`.
+func listError(ctx iris.Context, err error) error {
+	if err == sql.ErrUnprocessable {
+		ctx.StatusCode(iris.StatusUnprocessableEntity)
+		return errInvalidList
+	}
+
+	return err
+}
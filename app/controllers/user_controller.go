@@ -3,6 +3,7 @@
 package controllers
 
 import (
+	"morshed/data/engine/sql"
 	"morshed/data/models"
 	"morshed/domain/services"
 
@@ -68,21 +69,21 @@ func (c *UserController) GetRegister() mvc.Result {
 func (c *UserController) PostRegister() mvc.Result {
 	// get firstname, username and password from the form.
 	var (
-		firstname 		= c.Ctx.FormValue("firstname")
-		username  		= c.Ctx.FormValue("username")
-		password  		= c.Ctx.FormValue("password")
-		dob  			= "10/12/2009"
-		address  		= "California"
-		description 	= "My Test User"
+		firstname   = c.Ctx.FormValue("firstname")
+		username    = c.Ctx.FormValue("username")
+		password    = c.Ctx.FormValue("password")
+		dob         = "10/12/2009"
+		address     = "California"
+		description = "My Test User"
 	)
 
 	// create the new user, the password will be hashed by the service.
-	u, err := c.Service.CreateUser(password, models.User{
-		Username:  username,
-		Firstname: firstname,
-		Dob: dob,
-		Address: address,
-		Description: description,
+	u, err := c.Service.CreateUser(c.Ctx, password, models.User{
+		Username:    username,
+		Firstname:   firstname,
+		Dob:         sql.NewNullString(dob),
+		Address:     address,
+		Description: sql.NewNullString(description),
 	})
 
 	// set the user's id to this session even if err != nil,
@@ -127,7 +128,7 @@ func (c *UserController) PostLogin() mvc.Result {
 	)
 
 	attrs := map[string]interface{}{"username": username, "password": password}
-	u, err := c.Service.GetByAttrs(attrs)
+	u, err := c.Service.GetByAttrs(c.Ctx, attrs)
 
 	if err != nil {
 		return mvc.Response{
@@ -149,7 +150,7 @@ func (c *UserController) GetMe() mvc.Result {
 		return mvc.Response{Path: "/auth/login"}
 	}
 
-	u, err := c.Service.GetByID(c.getCurrentUserID())
+	u, err := c.Service.GetByID(c.Ctx, c.getCurrentUserID())
 	if err != nil {
 		// if the  session exists but for some reason the user doesn't exist in the "database"
 		// then logout and re-execute the function, it will redirect the client to the
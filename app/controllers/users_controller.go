@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"context"
+
 	"morshed/data/engine/sql"
 	"morshed/data/models"
+	"morshed/domain/repositories"
 	"morshed/domain/services"
 	"morshed/helpers"
 
 	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
 )
 
 // UsersController is our /users API controller.
@@ -14,6 +18,9 @@ import (
 // GET				/users/{id:int64} | get by id
 // PUT				/users/{id:int64} | update by id
 // DELETE			/users/{id:int64} | delete by id
+// POST				/users/{id:int64}/restore | restore a deleted user
+// DELETE			/users/{id:int64}/purge | delete permanently by id
+// GET requests accept ?include_deleted=true to include the deleted users.
 // Requires basic authentication.
 type UsersController struct {
 	// Optionally: context is auto-binded by Iris on each request,
@@ -27,34 +34,79 @@ type UsersController struct {
 	Service services.UserService
 }
 
-// Get returns list of the users.
+// Get returns a page of the users and the cursor of the next one,
+// filtered, sorted and paginated by the URL parameters, see `listOptions`.
 // Demo:
-// curl -i -u admin:password http://localhost:8080/users
+// curl -i -u admin:password http://localhost:8080/users?limit=20
 //
 // The correct way if you have sensitive data:
-// func (c *UsersController) Get() (results []viewmodels.User) {
-// 	data := c.Service.GetAll()
 //
-// 	for _, user := range data {
-// 		results = append(results, viewmodels.User{user})
-// 	}
-// 	return
-// }
+//	func (c *UsersController) Get() (results []viewmodels.User) {
+//		data := c.Service.GetAll()
+//
+//		for _, user := range data {
+//			results = append(results, viewmodels.User{user})
+//		}
+//		return
+//	}
+//
 // otherwise just return the datamodels.
-func (c *UsersController) Get() ([]models.User, error) {
-	users, err := c.Service.GetAll()
-	return users, err
+func (c *UsersController) Get() (repositories.Page[models.User], error) {
+	service, err := c.service()
+	if err != nil {
+		return repositories.Page[models.User]{}, err
+	}
+
+	page, err := service.List(c.Ctx, listOptions(c.Ctx))
+	return page, listError(c.Ctx, err)
+}
+
+// BeforeActivation registers the restore and purge routes.
+func (c *UsersController) BeforeActivation(b mvc.BeforeActivation) {
+	b.Handle(iris.MethodPost, "/{id:int64}/restore", "Restore")
+	b.Handle(iris.MethodDelete, "/{id:int64}/purge", "Purge")
+}
+
+// service returns the user service of the request,
+// the deleted users are included with ?include_deleted=true
+// and their ratings with ?include=ratings.
+func (c *UsersController) service() (services.UserService, error) {
+	service := c.Service
+	include, err := includeDeleted(c.Ctx)
+	if err != nil {
+		return service, err
+	}
+	if include {
+		service = service.Unscoped()
+	}
+
+	relations, err := includeRelations(c.Ctx, new(models.User))
+	if err != nil {
+		return service, err
+	}
+	if len(relations) > 0 {
+		service = service.Preload(relations...)
+	}
+
+	return service, nil
 }
 
 // GetBy returns a user.
 // Demo:
 // curl -i -u admin:password http://localhost:8080/users/1
 func (c *UsersController) GetBy(id int64) (models.User, error) {
-	user, err := c.Service.GetByID(id)
+	service, err := c.service()
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user, err := service.GetByID(c.Ctx, id)
 	if err != nil {
 		// this message will be binded to the
 		// main.go -> app.OnAnyErrorCode -> NotFound -> shared/error.html -> .Message text.
 		c.Ctx.Values().Set("message", "User couldn't be found!")
+	} else {
+		setValidators(c.Ctx, &user)
 	}
 	return user, err // it will throw/emit 404 if found == false.
 }
@@ -65,7 +117,7 @@ func (h *UsersController) Post() {
 		return
 	}
 
-	id, err := h.Service.Create(user)
+	u, err := h.Service.Create(h.Ctx, user)
 	if err != nil {
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity, helpers.MnewError(iris.StatusUnprocessableEntity, h.Ctx.Request().Method, h.Ctx.Path(), "required fields are missing"))
@@ -77,9 +129,8 @@ func (h *UsersController) Post() {
 		return
 	}
 
-	// Send 201 with body of {"id":$last_inserted_id"}.
-	h.Ctx.StatusCode(iris.StatusCreated)
-	h.Ctx.JSON(iris.Map{user.PrimaryKey(): id})
+	// Send 201 with the stored user and its URL.
+	helpers.MwriteCreated(h.Ctx, u.ID, u)
 }
 
 func (h *UsersController) Put() {
@@ -88,8 +139,27 @@ func (h *UsersController) Put() {
 		return
 	}
 
-	user, err := h.Service.Update(user)
+	// the If-Match header, if any, is the version the client has read.
+	matched, err := matchVersion(h.Ctx, &user)
 	if err != nil {
+		h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity,
+			helpers.MnewError(iris.StatusUnprocessableEntity,
+				h.Ctx.Request().Method, h.Ctx.Path(), "invalid If-Match header"))
+		return
+	}
+
+	user, err = h.Service.Update(h.Ctx, user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			helpers.MwriteEntityNotFound(h.Ctx)
+			return
+		}
+
+		if err == sql.ErrConflict {
+			writeConflict(h.Ctx, matched)
+			return
+		}
+
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity,
 				helpers.MnewError(iris.StatusUnprocessableEntity,
@@ -105,6 +175,8 @@ func (h *UsersController) Put() {
 	status := iris.StatusOK
 	if user.ID <= 0 {
 		status = iris.StatusNotModified
+	} else {
+		setETag(h.Ctx, &user)
 	}
 
 	h.Ctx.StatusCode(status)
@@ -113,13 +185,19 @@ func (h *UsersController) Put() {
 func (h *UsersController) Patch() {
 	id := h.Ctx.Params().GetInt64Default("id", 0)
 
-	var attrs map[string]interface{}
+	attrs := make(map[string]interface{})
 	if err := h.Ctx.ReadJSON(&attrs); err != nil {
 		return
 	}
+	matched := matchAttrsVersion(h.Ctx, new(models.User), attrs)
 
-	affected, err := h.Service.PatchUpdate(id, attrs)
+	affected, err := h.Service.PatchUpdate(h.Ctx, id, attrs)
 	if err != nil {
+		if err == sql.ErrConflict {
+			writeConflict(h.Ctx, matched)
+			return
+		}
+
 		if err == sql.ErrUnprocessable {
 			h.Ctx.StopWithJSON(iris.StatusUnprocessableEntity,
 				helpers.MnewError(iris.StatusUnprocessableEntity,
@@ -143,7 +221,7 @@ func (h *UsersController) Patch() {
 func (h *UsersController) Delete() {
 	id := h.Ctx.Params().GetInt64Default("id", 0)
 
-	affected, err := h.Service.DeleteByID(id)
+	affected, err := h.Service.DeleteByID(h.Ctx, id)
 	if err != nil {
 		helpers.Mdebugf("ProductHandler.Delete(DB): %v", err)
 		helpers.MwriteInternalServerError(h.Ctx)
@@ -157,3 +235,33 @@ func (h *UsersController) Delete() {
 
 	h.Ctx.StatusCode(status)
 }
+
+// Restore brings back a deleted user.
+// Demo:
+// curl -i -X POST -u admin:password http://localhost:8080/users/1/restore
+func (h *UsersController) Restore(id int64) {
+	h.write("Restore", id, h.Service.Restore)
+}
+
+// Purge removes a user permanently, even if it is deleted.
+// Demo:
+// curl -i -X DELETE -u admin:password http://localhost:8080/users/1/purge
+func (h *UsersController) Purge(id int64) {
+	h.write("Purge", id, h.Service.Purge)
+}
+
+func (h *UsersController) write(name string, id int64, fn func(context.Context, int64) (int, error)) {
+	affected, err := fn(h.Ctx, id)
+	if err != nil {
+		helpers.Mdebugf("UsersController.%s(DB): %v", name, err)
+		helpers.MwriteInternalServerError(h.Ctx)
+		return
+	}
+
+	status := iris.StatusOK
+	if affected == 0 {
+		status = iris.StatusNotModified
+	}
+
+	h.Ctx.StatusCode(status)
+}
@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"morshed/data/engine/sql"
+	"morshed/helpers"
+
+	"github.com/kataras/iris/v12"
+)
+
+// etagOf returns the entity tag of "rec", a pointer to a `sql.Versioned` record,
+// e.g. "3" for integer versions or "2006-01-02T15:04:05.999999999Z" for time versions,
+// or an empty string if it has no version.
+func etagOf(rec interface{}) string {
+	switch v, _ := sql.VersionOf(rec); version := v.(type) {
+	case int64:
+		return strconv.Quote(strconv.FormatInt(version, 10))
+	case time.Time:
+		return strconv.Quote(version.UTC().Format(time.RFC3339Nano))
+	default:
+		return ""
+	}
+}
+
+// setETag sets the ETag response header to the version of "rec", if any.
+func setETag(ctx iris.Context, rec interface{}) {
+	if etag := etagOf(rec); etag != "" {
+		ctx.Header("ETag", etag)
+	}
+}
+
+// setValidators sets the ETag and Last-Modified response headers of "rec", a record read by a GET request,
+// from its version and its "updated_at" column, see `middleware.Conditional`. The related records
+// of ?include= change without changing "rec", such a response is validated by its body instead.
+func setValidators(ctx iris.Context, rec interface{}) {
+	if ctx.URLParamExists("include") {
+		return
+	}
+
+	setETag(ctx, rec)
+	if updatedAt, ok := sql.UpdatedAtOf(rec); ok {
+		ctx.SetLastModified(updatedAt)
+	}
+}
+
+// ifMatch returns the version of the If-Match request header,
+// the ETag of a previous GET, or an empty string if it is missing or "*".
+func ifMatch(ctx iris.Context) string {
+	etag := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	etag = strings.TrimPrefix(etag, "W/")
+	if etag == "*" {
+		return ""
+	}
+
+	return strings.Trim(etag, `"`)
+}
+
+// matchVersion sets the version of "rec", a pointer to a `sql.Versioned` record,
+// to the one of the If-Match request header, if any. It reports whether the header was applied.
+func matchVersion(ctx iris.Context, rec interface{}) (bool, error) {
+	version := ifMatch(ctx)
+	if version == "" {
+		return false, nil
+	}
+
+	return true, sql.SetVersion(rec, version)
+}
+
+// matchAttrsVersion sets the version column of the "rec" record on the partial update "attrs"
+// to the version of the If-Match request header, if any. It reports whether the header was applied.
+func matchAttrsVersion(ctx iris.Context, rec sql.Record, attrs map[string]interface{}) bool {
+	version, column := ifMatch(ctx), sql.VersionColumnOf(rec)
+	if version == "" || column == "" {
+		return false
+	}
+
+	attrs[column] = version
+	return true
+}
+
+// writeConflict sends the error of an update of a record which has changed since it was read:
+// 412 if the expected version came from the If-Match header, otherwise 409.
+func writeConflict(ctx iris.Context, matched bool) {
+	status := iris.StatusConflict
+	if matched {
+		status = iris.StatusPreconditionFailed
+	}
+
+	ctx.StopWithJSON(status, helpers.MnewError(status, ctx.Request().Method, ctx.Path(), "the record has been changed, fetch it and try again"))
+}
@@ -0,0 +1,174 @@
+// Command migrate manages the versioned schema of the MySQL database.
+//
+// Usage:
+//
+//	go run ./cmd/migrate up [N]       apply all (or the next N) pending migrations
+//	go run ./cmd/migrate down [N]     revert the last (or the last N) applied migrations
+//	go run ./cmd/migrate status       list the migrations and whether they are applied
+//	go run ./cmd/migrate create NAME  write a new pair of empty migration files
+//	go run ./cmd/migrate reset        drop and re-create the database, then apply all migrations
+//
+// The connection is configured through the same MYSQL_* environment variables as the app.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"morshed/data/datasource"
+	"morshed/data/engine/sql"
+	"morshed/data/migrations"
+)
+
+func main() {
+	dir := flag.String("dir", migrations.DefaultDir, "the migrations directory")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: migrate [-dir %s] up [N] | down [N] | status | create NAME | reset\n", migrations.DefaultDir)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	database := datasource.MySQLDatabase()
+
+	switch cmd := args[0]; cmd {
+	case "create":
+		if len(args) < 2 {
+			log.Fatal("migrate create: missing migration name")
+		}
+
+		up, down, err := migrations.Create(*dir, args[1])
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("created %s\ncreated %s\n", up, down)
+	case "up", "down", "status":
+		if err := createDatabase(database, false); err != nil {
+			log.Fatal(err)
+		}
+
+		m, closeDB := connect(database, *dir)
+		defer closeDB()
+
+		run(ctx, m, cmd, steps(args))
+	case "reset":
+		if err := createDatabase(database, true); err != nil {
+			log.Fatal(err)
+		}
+
+		m, closeDB := connect(database, *dir)
+		defer closeDB()
+
+		run(ctx, m, "up", 0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func steps(args []string) int {
+	if len(args) < 2 {
+		return 0
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil || n < 0 {
+		log.Fatalf("migrate: invalid number of steps: %s", args[1])
+	}
+
+	return n
+}
+
+// createDatabase creates the "database" if it does not exist,
+// drops it first if "recreate" is true.
+func createDatabase(database string, recreate bool) error {
+	server, err := sql.ConnectMySQLWith(datasource.MySQLDSN(""), connOptions())
+	if err != nil {
+		return err
+	}
+	defer server.Conn.Close()
+
+	exists, err := server.HasDatabase(database)
+	if err != nil {
+		return err
+	}
+
+	if exists && recreate {
+		if err = server.Drop(database); err != nil {
+			return err
+		}
+		exists = false
+	}
+
+	if !exists {
+		log.Printf("creating database %s", database)
+		return server.CreateDatabase(database)
+	}
+
+	return nil
+}
+
+// connOptions returns the connection settings of the app, see `datasource.MySQLConnOptions`,
+// without the query timeout as migrations may run longer.
+func connOptions() sql.ConnOptions {
+	opts := datasource.MySQLConnOptions()
+	opts.QueryTimeout = 0
+	return opts
+}
+
+func connect(database, dir string) (*migrations.Migrator, func()) {
+	db, err := sql.ConnectMySQLWith(datasource.MySQLDSN(database), connOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return migrations.New(db, dir), func() { db.Conn.Close() }
+}
+
+func run(ctx context.Context, m *migrations.Migrator, cmd string, n int) {
+	switch cmd {
+	case "up":
+		done, err := m.Up(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("applied  %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		if n == 0 {
+			n = 1 // revert one at a time unless told otherwise.
+		}
+
+		done, err := m.Down(ctx, n)
+		for _, mig := range done {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	}
+}
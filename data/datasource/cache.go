@@ -0,0 +1,174 @@
+package datasource
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"morshed/data/engine/cache"
+	"morshed/data/engine/sql"
+	"morshed/data/models"
+	"morshed/data/repositories"
+	domain "morshed/domain/repositories"
+	"morshed/helpers"
+)
+
+// The cache budgets of the models, records which rarely change,
+// e.g. countries, are kept longer than the ones written by the clients, e.g. ratings.
+func init() {
+	const kb, mb = 1 << 10, 1 << 20
+
+	cache.Register(new(models.User), cache.Model{MaxAge: 30 * time.Second, Size: 4 * mb})
+	cache.Register(new(models.Product), cache.Model{MaxAge: time.Minute, Size: 8 * mb})
+	cache.Register(new(models.Country), cache.Model{MaxAge: time.Hour, Size: 512 * kb})
+	cache.Register(new(models.Governorate), cache.Model{MaxAge: time.Hour, Size: mb})
+	cache.Register(new(models.Category), cache.Model{MaxAge: 10 * time.Minute, Size: mb})
+	cache.Register(new(models.Destination), cache.Model{MaxAge: 5 * time.Minute, Size: 16 * mb})
+	cache.Register(new(models.Station), cache.Model{MaxAge: 10 * time.Minute, Size: 4 * mb})
+	cache.Register(new(models.Transportation), cache.Model{MaxAge: 5 * time.Minute, Size: 8 * mb})
+	cache.Register(new(models.Route), cache.Model{MaxAge: 5 * time.Minute, Size: 4 * mb})
+	cache.Register(new(models.DestRating), cache.Model{MaxAge: time.Minute, Size: 8 * mb})
+	cache.Register(new(models.TransRating), cache.Model{MaxAge: time.Minute, Size: 4 * mb})
+}
+
+// Cache returns the cache of the "rec" records, named after their table.
+// It is created on first use with the budget of the record's `cache.Model`.
+// The cache reads through the MySQL or Postgres database, it is not available for the other engines.
+func (s *Source) Cache(rec sql.Record) (*cache.Cache, error) {
+	if s.DB == nil {
+		return nil, fmt.Errorf("cache is not available for the %s engine", s.Engine)
+	}
+
+	s.cachesMu.Lock()
+	defer s.cachesMu.Unlock()
+
+	name := rec.TableName()
+	c, ok := s.caches[name]
+	if !ok {
+		c = cache.New(sql.NewRepository(s.DB, rec), name, 0)
+		if s.caches == nil {
+			s.caches = make(map[string]*cache.Cache)
+		}
+		s.caches[name] = c
+	}
+
+	return c, nil
+}
+
+// CacheEnabled reports whether the repositories read through their cache,
+// set by the CACHE_ENABLED env variable, defaults to false.
+func CacheEnabled() bool {
+	enabled, _ := strconv.ParseBool(helpers.Mgetenv("CACHE_ENABLED", "false"))
+	return enabled
+}
+
+// cached returns the "repo" of the T records on top of their cache, if `CacheEnabled`.
+// The users are never cached, their hashed password is not part of their JSON form.
+func cached[T any, R domain.Record[T]](s *Source, repo domain.Repository[T]) domain.Repository[T] {
+	if !CacheEnabled() {
+		return repo
+	}
+
+	c, err := s.Cache(R(new(T)))
+	if err != nil {
+		return repo
+	}
+
+	return repositories.NewCachedRepository[T, R](repo, c)
+}
+
+// CacheSelf returns the base URL the peers reach this process on, e.g. "http://10.0.0.1:8080",
+// set by the CACHE_SELF env variable. The caches are not shared if it is empty, see `CacheDiscovery`.
+func CacheSelf() string {
+	return helpers.Mgetenv("CACHE_SELF", "")
+}
+
+// CacheDiscovery returns how the peers which share the caches are found, set by one of the env variables:
+// CACHE_PEERS, a comma separated list of base URLs, e.g. "http://10.0.0.1:8080,http://10.0.0.2:8080",
+// CACHE_PEERS_DNS, a host name resolved to the peers and their port, e.g. "morshed:8080",
+// or CACHE_PEERS_FILE, a membership file which the processes register themselves to.
+// It returns nil if none of them is set.
+func CacheDiscovery() (cache.Discovery, error) {
+	if peers := helpers.Mgetenv("CACHE_PEERS", ""); peers != "" {
+		var list cache.StaticPeers
+		for _, peer := range strings.Split(peers, ",") {
+			if peer = strings.TrimSpace(peer); peer != "" {
+				list = append(list, peer)
+			}
+		}
+		return list, nil
+	}
+
+	if hostport := helpers.Mgetenv("CACHE_PEERS_DNS", ""); hostport != "" {
+		host, port, err := net.SplitHostPort(hostport)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_PEERS_DNS %q: %w", hostport, err)
+		}
+		return cache.DNSPeers{Host: host, Port: port}, nil
+	}
+
+	if path := helpers.Mgetenv("CACHE_PEERS_FILE", ""); path != "" {
+		return cache.FilePeers{Path: path}, nil
+	}
+
+	return nil, nil
+}
+
+// startCachePool joins the peers of `CacheDiscovery` when the cache is enabled and `CacheSelf` is set,
+// CACHE_PEERS_REFRESH sets how often the peers are discovered again (defaults to 10s)
+// and CACHE_SECRET the secret the peers share, see `cache.PoolOptions.Secret`.
+// It returns a nil pool otherwise, the caches are local to the process.
+func startCachePool() (*cache.Pool, error) {
+	self := CacheSelf()
+	if !CacheEnabled() || self == "" {
+		return nil, nil
+	}
+
+	discovery, err := CacheDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.NewPool(self, cache.PoolOptions{
+		Discovery:       discovery,
+		RefreshInterval: envDuration("CACHE_PEERS_REFRESH", 10*time.Second),
+		Secret:          helpers.Mgetenv("CACHE_SECRET", ""),
+	})
+}
+
+// CachePool returns the peers which share the caches, nil if they are not shared.
+func (s *Source) CachePool() *cache.Pool {
+	return s.pool
+}
+
+// CacheReport holds the statistics of the caches of a process, see `Source.CacheReport`.
+type CacheReport struct {
+	Self   string            `json:"self,omitempty"`
+	Caches []cache.Stats     `json:"caches"`
+	Peers  []cache.PeerStats `json:"peers"`
+}
+
+// CacheReport returns the statistics of the caches created so far, by name,
+// and of the requests to the peers.
+func (s *Source) CacheReport() CacheReport {
+	report := CacheReport{Caches: []cache.Stats{}, Peers: []cache.PeerStats{}}
+
+	s.cachesMu.Lock()
+	for _, c := range s.caches {
+		report.Caches = append(report.Caches, c.Stats())
+	}
+	s.cachesMu.Unlock()
+	sort.Slice(report.Caches, func(i, j int) bool {
+		return report.Caches[i].Name < report.Caches[j].Name
+	})
+
+	if s.pool != nil {
+		report.Self = s.pool.Self()
+		report.Peers = s.pool.PeerStats()
+	}
+
+	return report
+}
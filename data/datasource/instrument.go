@@ -0,0 +1,48 @@
+package datasource
+
+import (
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"morshed/data/engine/sql"
+	middleware "morshed/domain/middlewares"
+	"morshed/helpers"
+)
+
+// Instrument returns the "db" decorated with the query instrumentation, see `sql.Instrumented`,
+// and the slow query log file which should be closed along with the database.
+// It is configured by the env variables:
+// SQL_LOG_QUERIES=true logs every query,
+// SQL_SLOW_THRESHOLD sets the duration of a slow query (defaults to 500ms, 0 disables the slow query log)
+// and SQL_SLOW_LOG sets the file of the slow query log (defaults to ./slow-query.log).
+func Instrument(db sql.Database) (*sql.Instrumented, io.Closer, error) {
+	var opts sql.InstrumentOptions
+	opts.RequestID = middleware.RequestID
+
+	if logQueries, _ := strconv.ParseBool(helpers.Mgetenv("SQL_LOG_QUERIES", "false")); logQueries {
+		opts.Log = func(e sql.QueryEntry) {
+			helpers.Mdebugf("sql: %s", e)
+		}
+	}
+
+	threshold := envDuration("SQL_SLOW_THRESHOLD", 500*time.Millisecond)
+	if threshold <= 0 {
+		return sql.Instrument(db, opts), nil, nil
+	}
+
+	f, err := os.OpenFile(helpers.Mgetenv("SQL_SLOW_LOG", "./slow-query.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	slowLog := log.New(f, "", log.LstdFlags)
+	opts.SlowThreshold = threshold
+	opts.SlowLog = func(e sql.QueryEntry) {
+		slowLog.Println(e)
+	}
+
+	return sql.Instrument(db, opts), f, nil
+}
@@ -0,0 +1,87 @@
+package datasource
+
+import (
+	"context"
+	stdsql "database/sql"
+	"io/ioutil"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"morshed/app/controllers"
+	"morshed/data/engine/sql"
+	"morshed/data/models"
+	"morshed/data/repositories"
+	middleware "morshed/domain/middlewares"
+	"morshed/domain/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/middleware/requestid"
+	"github.com/kataras/iris/v12/mvc"
+)
+
+// noRows is a `sql.Database` without any rows.
+type noRows struct{}
+
+func (noRows) Get(context.Context, interface{}, string, ...interface{}) error { return sql.ErrNoRows }
+func (noRows) Select(context.Context, interface{}, string, ...interface{}) error {
+	return sql.ErrNoRows
+}
+func (noRows) Exec(context.Context, string, ...interface{}) (stdsql.Result, error) {
+	return nil, sql.ErrNoRows
+}
+
+func TestInstrumentRequestID(t *testing.T) {
+	slowLog := filepath.Join(t.TempDir(), "slow-query.log")
+	t.Setenv("SQL_SLOW_THRESHOLD", "1ns") // every query is slow.
+	t.Setenv("SQL_SLOW_LOG", slowLog)
+
+	db, closer, err := Instrument(noRows{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closer.Close()
+
+	app := iris.New()
+	app.Use(requestid.New(), middleware.RequestContext)
+	prod := mvc.New(app.Party("/product"))
+	prod.Register(services.NewProductService(repositories.NewSQLRepository[models.Product](db)))
+	prod.Handle(new(controllers.ProductController))
+	if err = app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/product/7", nil)
+	req.Header.Set("X-Request-Id", "req-42")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	b, err := ioutil.ReadFile(slowLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); !strings.Contains(got, "[req-42] SELECT * FROM products") {
+		t.Fatalf("expected the query of the request to be logged with its id but got %q", got)
+	}
+}
+
+func TestRequestIDOfDerivedContext(t *testing.T) {
+	app := iris.New()
+	app.Use(requestid.New(), middleware.RequestContext)
+
+	var got string
+	app.Get("/", func(ctx iris.Context) {
+		got = middleware.RequestID(sql.WithPrimary(sql.WithDeleted(ctx)))
+	})
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-Id", "req-43")
+	app.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "req-43" {
+		t.Fatalf("expected request id %q but got %q", "req-43", got)
+	}
+}
@@ -5,6 +5,10 @@ package datasource
 import (
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	"morshed/data/engine/sql"
 	"morshed/helpers"
@@ -21,6 +25,8 @@ const (
 	Bolt
 	// MySQL for mysql-compatible source location.
 	MySQL
+	// Postgres for PostgreSQL source location.
+	Postgres
 )
 
 // LoadUsers returns all users(empty map) from the memory, for the sake of simplicty.
@@ -29,17 +35,144 @@ func StartMySql(engine Engine) (sql.Database, error) {
 		return nil, errors.New("Only MySql available")
 	}
 
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:3306)/%s?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci",
+	opts := MySQLConnOptions()
+	db, err := sql.ConnectMySQLWith(MySQLDSN(MySQLDatabase()), opts)
+	if err != nil {
+		return nil, errors.Unwrap(fmt.Errorf("error connecting to the MySQL database:  %w", err))
+	}
+
+	hosts := MySQLReplicaHosts()
+	if len(hosts) == 0 {
+		return db, nil
+	}
+
+	replicas := make([]sql.Database, 0, len(hosts))
+	for _, host := range hosts {
+		// replicas may be down on start, the health checks bring them in later.
+		replica, err := sql.OpenMySQLWith(mysqlDSN(host, MySQLDatabase()), opts)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("error opening the MySQL replica %s: %w", host, err)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return sql.NewReplicated(db, replicas, ReplicaOptions()), nil
+}
+
+// MySQLConnOptions returns the connection pool, timeout and retry settings of the MySQL database,
+// set by the env variables, see `sql.ConnOptions`:
+// MYSQL_MAX_OPEN_CONNS (defaults to 10), MYSQL_MAX_IDLE_CONNS (defaults to 5),
+// MYSQL_CONN_MAX_LIFETIME (defaults to 5m), MYSQL_CONN_MAX_IDLE_TIME (defaults to 1m),
+// MYSQL_QUERY_TIMEOUT (defaults to 10s), MYSQL_CONNECT_RETRIES (defaults to 5),
+// MYSQL_CONNECT_BACKOFF (defaults to 1s) and MYSQL_READ_RETRIES (defaults to 2).
+// The pool defaults match the max_open_con and max_idle_con of the admin panel's config.json.
+func MySQLConnOptions() sql.ConnOptions {
+	return connOptions("MYSQL_")
+}
+
+// PostgresConnOptions same as `MySQLConnOptions` but for the Postgres database,
+// with the same defaults: POSTGRES_MAX_OPEN_CONNS, POSTGRES_MAX_IDLE_CONNS,
+// POSTGRES_CONN_MAX_LIFETIME, POSTGRES_CONN_MAX_IDLE_TIME, POSTGRES_QUERY_TIMEOUT,
+// POSTGRES_CONNECT_RETRIES, POSTGRES_CONNECT_BACKOFF and POSTGRES_READ_RETRIES.
+func PostgresConnOptions() sql.ConnOptions {
+	return connOptions("POSTGRES_")
+}
+
+// connOptions returns the connection settings of the env variables which start with "prefix".
+func connOptions(prefix string) sql.ConnOptions {
+	return sql.ConnOptions{
+		MaxOpenConns:    envInt(prefix+"MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    envInt(prefix+"MAX_IDLE_CONNS", 5),
+		ConnMaxLifetime: envDuration(prefix+"CONN_MAX_LIFETIME", 5*time.Minute),
+		ConnMaxIdleTime: envDuration(prefix+"CONN_MAX_IDLE_TIME", time.Minute),
+		QueryTimeout:    envDuration(prefix+"QUERY_TIMEOUT", 10*time.Second),
+		ConnectRetries:  envInt(prefix+"CONNECT_RETRIES", 5),
+		ConnectBackoff:  envDuration(prefix+"CONNECT_BACKOFF", time.Second),
+		ReadRetries:     envInt(prefix+"READ_RETRIES", 2),
+	}
+}
+
+// envInt returns the integer value of the "key" env variable or "def" if it is missing or invalid.
+func envInt(key string, def int) int {
+	n, err := strconv.Atoi(helpers.Mgetenv(key, ""))
+	if err != nil {
+		return def
+	}
+
+	return n
+}
+
+// envDuration returns the duration value of the "key" env variable, e.g. "5s",
+// or "def" if it is missing or invalid.
+func envDuration(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(helpers.Mgetenv(key, ""))
+	if err != nil {
+		return def
+	}
+
+	return d
+}
+
+// MySQLReplicaHosts returns the hosts of the read replicas,
+// set by the comma separated MYSQL_REPLICA_HOSTS env variable, e.g. "10.0.0.2,10.0.0.3:3307".
+// Reads are spread over the replicas and writes go to the MYSQL_HOST, see `sql.Replicated`.
+func MySQLReplicaHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(helpers.Mgetenv("MYSQL_REPLICA_HOSTS", ""), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+
+	return hosts
+}
+
+// ReplicaOptions returns the health check settings of the read replicas,
+// set by the REPLICA_CHECK_INTERVAL and REPLICA_CHECK_TIMEOUT env variables, e.g. "10s".
+func ReplicaOptions() sql.ReplicaOptions {
+	return sql.ReplicaOptions{
+		CheckInterval: envDuration("REPLICA_CHECK_INTERVAL", 5*time.Second),
+		CheckTimeout:  envDuration("REPLICA_CHECK_TIMEOUT", 2*time.Second),
+	}
+}
+
+// ReadYourWritesWindow returns how long the reads of a client go to the primary after its writes,
+// set by the REPLICA_READ_YOUR_WRITES env variable, defaults to 5 seconds, see `middleware.ReadYourWrites`.
+// It should be longer than the replication lag of the replicas.
+func ReadYourWritesWindow() time.Duration {
+	return envDuration("REPLICA_READ_YOUR_WRITES", 5*time.Second)
+}
+
+// MySQLDatabase returns the name of the application's database.
+func MySQLDatabase() string {
+	return helpers.Mgetenv("MYSQL_DATABASE", "morshed-db")
+}
+
+// MySQLDSN returns the connection string of the "database",
+// pass an empty "database" to connect to the server only.
+// Extra parameters, e.g. "multiStatements=true", are appended to the defaults.
+func MySQLDSN(database string, params ...string) string {
+	return mysqlDSN(helpers.Mgetenv("MYSQL_HOST", "127.0.0.1"), database, params...)
+}
+
+// mysqlDSN returns the connection string of the "database" on the "host",
+// the port defaults to 3306.
+func mysqlDSN(host, database string, params ...string) string {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "3306")
+	}
+
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci",
 		helpers.Mgetenv("MYSQL_USER", "root"),
 		helpers.Mgetenv("MYSQL_PASSWORD", "BugSquad#2022"),
-		helpers.Mgetenv("MYSQL_HOST", "127.0.0.1"),
-		helpers.Mgetenv("MYSQL_DATABASE", "morshed-db"),
+		host,
+		database,
 	)
 
-	db, err := sql.ConnectMySQL(dsn)
-	if err != nil {
-		return nil, errors.Unwrap(fmt.Errorf("error connecting to the MySQL database:  %w", err))
+	for _, p := range params {
+		dsn += "&" + p
 	}
 
-	return db, nil
+	return dsn
 }
@@ -0,0 +1,197 @@
+package datasource
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+
+	"morshed/data/engine/bolt"
+	"morshed/data/engine/cache"
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+	"morshed/data/models"
+	"morshed/data/repositories"
+	domain "morshed/domain/repositories"
+	"morshed/helpers"
+)
+
+// String returns the name of the engine, as accepted by `ParseEngine`.
+func (e Engine) String() string {
+	switch e {
+	case Memory:
+		return "memory"
+	case Bolt:
+		return "bolt"
+	case MySQL:
+		return "mysql"
+	case Postgres:
+		return "postgres"
+	default:
+		return fmt.Sprintf("engine(%d)", uint32(e))
+	}
+}
+
+// ParseEngine returns the Engine of a "memory", "bolt", "mysql" or "postgres" name.
+func ParseEngine(name string) (Engine, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "memory", "mem":
+		return Memory, nil
+	case "bolt", "boltdb":
+		return Bolt, nil
+	case "mysql", "":
+		return MySQL, nil
+	case "postgres", "postgresql":
+		return Postgres, nil
+	default:
+		return 0, fmt.Errorf("unknown datasource engine: %q", name)
+	}
+}
+
+// EngineFromEnv returns the Engine set by the DATASOURCE_ENGINE env variable, defaults to MySQL.
+func EngineFromEnv() (Engine, error) {
+	return ParseEngine(helpers.Mgetenv("DATASOURCE_ENGINE", "mysql"))
+}
+
+// BoltPath returns the file of the bolt database, set by the BOLT_PATH env variable.
+func BoltPath() string {
+	return helpers.Mgetenv("BOLT_PATH", "./morshed.db")
+}
+
+// PostgresDSN returns the connection string of the Postgres database,
+// set by the POSTGRES_DSN env variable or built from the POSTGRES_* ones.
+func PostgresDSN() string {
+	if dsn := helpers.Mgetenv("POSTGRES_DSN", ""); dsn != "" {
+		return dsn
+	}
+
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(helpers.Mgetenv("POSTGRES_USER", "postgres"), helpers.Mgetenv("POSTGRES_PASSWORD", "BugSquad#2022")),
+		Host:     helpers.Mgetenv("POSTGRES_HOST", "127.0.0.1") + ":5432",
+		Path:     "/" + helpers.Mgetenv("POSTGRES_DATABASE", "morshed-db"),
+		RawQuery: "sslmode=" + helpers.Mgetenv("POSTGRES_SSLMODE", "disable"),
+	}
+
+	return u.String()
+}
+
+// Source holds the storage of an Engine
+// and creates the data repositories on top of it.
+type Source struct {
+	Engine Engine
+	DB     sql.Database // the MySQL or Postgres database, nil for the other engines.
+	mem    *memory.DB
+	bolt   *bolt.DB
+
+	queries *sql.Instrumented // records the queries of the DB, see `QueryStats`.
+	slowLog io.Closer
+
+	cachesMu sync.Mutex
+	caches   map[string]*cache.Cache // by table name, see `Cache`.
+	pool     *cache.Pool             // the peers which share the caches, see `CachePool`.
+}
+
+// Open starts the storage of the "engine".
+func Open(engine Engine) (*Source, error) {
+	switch engine {
+	case Memory:
+		return &Source{Engine: engine, mem: memory.New()}, nil
+	case Bolt:
+		db, err := bolt.Open(BoltPath())
+		if err != nil {
+			return nil, fmt.Errorf("error opening the bolt database: %w", err)
+		}
+		return &Source{Engine: engine, bolt: db}, nil
+	case MySQL:
+		db, err := StartMySql(engine)
+		if err != nil {
+			return nil, err
+		}
+		return newSQLSource(engine, db)
+	case Postgres:
+		db, err := sql.ConnectPostgresWith(PostgresDSN(), PostgresConnOptions())
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to the Postgres database: %w", err)
+		}
+		return newSQLSource(engine, db)
+	default:
+		return nil, fmt.Errorf("datasource engine %s is not available", engine)
+	}
+}
+
+func newSQLSource(engine Engine, db sql.Database) (*Source, error) {
+	queries, slowLog, err := Instrument(db)
+	if err != nil {
+		if c, ok := db.(io.Closer); ok {
+			c.Close()
+		}
+		return nil, fmt.Errorf("error opening the slow query log: %w", err)
+	}
+
+	pool, err := startCachePool()
+	if err != nil {
+		if slowLog != nil {
+			slowLog.Close()
+		}
+		queries.Close()
+		return nil, fmt.Errorf("error starting the cache peer pool: %w", err)
+	}
+
+	return &Source{Engine: engine, DB: queries, queries: queries, slowLog: slowLog, pool: pool}, nil
+}
+
+// QueryStats returns the statistics of the database queries by their shape,
+// the most time consuming first. It is empty for the memory and bolt engines.
+func (s *Source) QueryStats() []sql.QueryStats {
+	if s.queries == nil {
+		return nil
+	}
+
+	return s.queries.Stats()
+}
+
+// Close releases the storage of the engine.
+func (s *Source) Close() error {
+	switch {
+	case s.bolt != nil:
+		return s.bolt.Close()
+	case s.DB != nil:
+		if s.pool != nil {
+			s.pool.Close()
+		}
+		if s.slowLog != nil {
+			s.slowLog.Close()
+		}
+
+		// *sql.Instrumented, *sql.MySQL, *sql.Postgres and *sql.Replicated.
+		if c, ok := s.DB.(io.Closer); ok {
+			return c.Close()
+		}
+	}
+
+	return nil
+}
+
+// Users returns the repository of the users.
+func (s *Source) Users() domain.Repository[models.User] {
+	return open[models.User](s)
+}
+
+// Products returns the repository of the products.
+func (s *Source) Products() domain.Repository[models.Product] {
+	return cached(s, open[models.Product](s))
+}
+
+// open returns the repository of the T records on the storage of the engine.
+func open[T any, R domain.Record[T]](s *Source) domain.Repository[T] {
+	switch {
+	case s.mem != nil:
+		return repositories.NewMemoryRepository[T, R](s.mem)
+	case s.bolt != nil:
+		return repositories.NewBoltRepository[T, R](s.bolt)
+	default:
+		return repositories.NewSQLRepository[T, R](s.DB)
+	}
+}
@@ -0,0 +1,589 @@
+// Package bolt is an embedded storage engine on top of a bbolt file.
+//
+// Each `sql.Record` type is kept on its own bucket, named after its `TableName`,
+// which holds a "rows" bucket of JSON records by their auto increment primary key
+// and one index bucket for each of the `Sorted.SortBy` columns.
+package bolt
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	rowsBucket  = []byte("rows")
+	indexPrefix = "idx:"
+)
+
+// DB holds the underline bbolt database file.
+// See the `Open` package-level function.
+type DB struct {
+	Conn *bbolt.DB
+
+	mu     sync.Mutex
+	tables map[string]*Table
+}
+
+// Open returns a new ready to use bolt DB stored on the "path" file, it is created if it does not exist.
+func Open(path string) (*DB, error) {
+	conn, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{Conn: conn, tables: make(map[string]*Table)}, nil
+}
+
+// Close releases the database file.
+func (db *DB) Close() error {
+	return db.Conn.Close()
+}
+
+// Table returns the table of the "rec" record.
+func (db *DB) Table(rec sql.Record) *Table {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[rec.TableName()]
+	if !ok {
+		t = newTable(db.Conn, rec)
+		db.tables[rec.TableName()] = t
+	}
+
+	return t
+}
+
+// Table stores the records of a single `sql.Record` type.
+// It has the same methods and semantics as the `memory.Table`.
+type Table struct {
+	conn    *bbolt.DB
+	rec     sql.Record
+	name    []byte
+	typ     reflect.Type
+	fields  map[string][]int        // column name to field index.
+	indexes []string                // the `SortBy` columns.
+	schema  *memory.Table           // converts values on `PartialUpdate`, holds no rows.
+	updates map[string]reflect.Kind // the `sql.UpdateSchemaOf` columns, see `PartialUpdate`.
+}
+
+func newTable(conn *bbolt.DB, rec sql.Record) *Table {
+	typ := reflect.TypeOf(rec)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	t := &Table{
+		conn:    conn,
+		rec:     rec,
+		name:    []byte(rec.TableName()),
+		typ:     typ,
+		fields:  sql.FieldsOf(typ),
+		schema:  memory.NewTable(rec),
+		updates: sql.UpdateSchemaOf(rec),
+	}
+
+	if b, ok := rec.(sql.Sorted); ok {
+		for _, column := range strings.Split(b.SortBy(), ",") {
+			if column = strings.TrimSpace(column); column != "" && column != rec.PrimaryKey() {
+				if _, ok := t.fields[column]; ok {
+					t.indexes = append(t.indexes, column)
+				}
+			}
+		}
+	}
+
+	return t
+}
+
+// RecordInfo returns the record info of the table.
+func (t *Table) RecordInfo() sql.Record {
+	return t.rec
+}
+
+// New returns a pointer to a new zero record of the table's type.
+func (t *Table) New() interface{} {
+	return reflect.New(t.typ).Interface()
+}
+
+func (t *Table) id(row reflect.Value) int64 {
+	index, ok := t.fields[t.rec.PrimaryKey()]
+	if !ok {
+		return 0
+	}
+
+	return row.FieldByIndex(index).Int()
+}
+
+// structValue returns the struct value of "v" which may be a struct or a pointer to it.
+func (t *Table) structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, sql.ErrUnprocessable
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() != t.typ {
+		return reflect.Value{}, sql.ErrUnprocessable
+	}
+
+	row := reflect.New(t.typ).Elem()
+	row.Set(rv)
+	return row, nil
+}
+
+// rows returns the rows bucket of the table, nil if it was never written.
+func (t *Table) rows(tx *bbolt.Tx) *bbolt.Bucket {
+	b := tx.Bucket(t.name)
+	if b == nil {
+		return nil
+	}
+
+	return b.Bucket(rowsBucket)
+}
+
+func (t *Table) index(tx *bbolt.Tx, column string) *bbolt.Bucket {
+	b := tx.Bucket(t.name)
+	if b == nil {
+		return nil
+	}
+
+	return b.Bucket([]byte(indexPrefix + column))
+}
+
+// writable creates the buckets of the table, if missing, and returns its rows bucket.
+func (t *Table) writable(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	b, err := tx.CreateBucketIfNotExists(t.name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, column := range t.indexes {
+		if _, err = b.CreateBucketIfNotExists([]byte(indexPrefix + column)); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.CreateBucketIfNotExists(rowsBucket)
+}
+
+func (t *Table) get(tx *bbolt.Tx, id int64) (reflect.Value, bool, error) {
+	rows := t.rows(tx)
+	if rows == nil {
+		return reflect.Value{}, false, nil
+	}
+
+	data := rows.Get(itob(id))
+	if data == nil {
+		return reflect.Value{}, false, nil
+	}
+
+	row, err := t.decode(data)
+	return row, err == nil, err
+}
+
+// put stores the "row" and updates its index entries, "old" is the previous version, if any.
+func (t *Table) put(tx *bbolt.Tx, rows *bbolt.Bucket, id int64, row reflect.Value, old *reflect.Value) error {
+	data, err := t.encode(row)
+	if err != nil {
+		return err
+	}
+
+	for _, column := range t.indexes {
+		idx := t.index(tx, column)
+		if old != nil {
+			if err = idx.Delete(t.indexKey(*old, column, id)); err != nil {
+				return err
+			}
+		}
+
+		if err = idx.Put(t.indexKey(row, column, id), nil); err != nil {
+			return err
+		}
+	}
+
+	return rows.Put(itob(id), data)
+}
+
+// each calls "fn" for each row of the table in primary key order, until "fn" returns false.
+func (t *Table) each(tx *bbolt.Tx, fn func(row reflect.Value) bool) error {
+	rows := t.rows(tx)
+	if rows == nil {
+		return nil
+	}
+
+	c := rows.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		row, err := t.decode(v)
+		if err != nil {
+			return err
+		}
+
+		if !fn(row) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// bindAll appends the "rows" to "dest", a pointer to a slice of the table's struct or pointers to it.
+// Like the models' `Scannable` implementations, it returns `sql.ErrNoRows` when "rows" is empty.
+func (t *Table) bindAll(dest interface{}, rows []reflect.Value) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return sql.ErrUnprocessable
+	}
+
+	list := rv.Elem()
+	elemType := list.Type().Elem()
+	for _, row := range rows {
+		switch {
+		case elemType == t.typ:
+			list = reflect.Append(list, row)
+		case elemType.Kind() == reflect.Ptr && elemType.Elem() == t.typ:
+			list = reflect.Append(list, row.Addr())
+		default:
+			return sql.ErrUnprocessable
+		}
+	}
+
+	if len(rows) == 0 {
+		return sql.ErrNoRows
+	}
+
+	rv.Elem().Set(list)
+	return nil
+}
+
+// hidden reports whether the "row" is soft deleted and should be hidden on "ctx",
+// see `sql.SoftDeletable`.
+func (t *Table) hidden(ctx context.Context, row reflect.Value) bool {
+	return !sql.IncludesDeleted(ctx) && sql.IsDeleted(row.Addr().Interface())
+}
+
+// Count returns the total records count in the table.
+func (t *Table) Count(ctx context.Context) (total int64, err error) {
+	err = t.conn.View(func(tx *bbolt.Tx) error {
+		if sql.SoftDeleteColumnOf(t.rec) != "" {
+			return t.each(tx, func(row reflect.Value) bool {
+				if !t.hidden(ctx, row) {
+					total++
+				}
+				return true
+			})
+		}
+
+		if rows := t.rows(tx); rows != nil {
+			total = int64(rows.Stats().KeyN)
+		}
+		return nil
+	})
+
+	return
+}
+
+// GetByID binds a single record to the "dest".
+func (t *Table) GetByID(ctx context.Context, dest interface{}, id int64) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != t.typ {
+		return sql.ErrUnprocessable
+	}
+
+	return t.conn.View(func(tx *bbolt.Tx) error {
+		row, ok, err := t.get(tx, id)
+		if err != nil {
+			return err
+		}
+		if !ok || t.hidden(ctx, row) {
+			return sql.ErrNoRows
+		}
+
+		rv.Elem().Set(row)
+		return nil
+	})
+}
+
+// GetByAttrs binds the first record that matches all the "attrs" column-value pairs to the "dest".
+func (t *Table) GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != t.typ {
+		return sql.ErrUnprocessable
+	}
+
+	var filters []sql.Filter
+	for column, value := range attrs {
+		filters = append(filters, sql.Eq(column, value))
+	}
+
+	list := reflect.New(reflect.SliceOf(t.typ))
+	opts := sql.ListOptions{OrderByColumn: t.rec.PrimaryKey(), Limit: 1}.Match(filters...)
+	if err := t.List(ctx, list.Interface(), opts); err != nil {
+		return err
+	}
+
+	rv.Elem().Set(list.Elem().Index(0))
+	return nil
+}
+
+// GetAll binds all the records of the table to the "dest".
+func (t *Table) GetAll(ctx context.Context, dest interface{}) error {
+	var rows []reflect.Value
+	err := t.conn.View(func(tx *bbolt.Tx) error {
+		return t.each(tx, func(row reflect.Value) bool {
+			if !t.hidden(ctx, row) {
+				rows = append(rows, row)
+			}
+			return true
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return t.bindAll(dest, rows)
+}
+
+// DeleteByID removes a single record from the table,
+// `sql.SoftDeletable` records are marked as deleted instead, see `Purge`.
+func (t *Table) DeleteByID(ctx context.Context, id int64) (int, error) {
+	column := sql.SoftDeleteColumnOf(t.rec)
+	if column == "" {
+		return t.Purge(ctx, id)
+	}
+
+	return t.setDeleted(id, column, true)
+}
+
+// Restore brings back the soft deleted "id" record, see `sql.Repository.Restore`.
+func (t *Table) Restore(ctx context.Context, id int64) (int, error) {
+	column := sql.SoftDeleteColumnOf(t.rec)
+	if column == "" {
+		return 0, sql.ErrUnprocessable
+	}
+
+	return t.setDeleted(id, column, false)
+}
+
+func (t *Table) setDeleted(id int64, column string, deleted bool) (n int, err error) {
+	err = t.conn.Update(func(tx *bbolt.Tx) error {
+		old, ok, err := t.get(tx, id)
+		if err != nil || !ok || sql.IsDeleted(old.Addr().Interface()) == deleted {
+			return err
+		}
+
+		row := reflect.New(t.typ).Elem()
+		row.Set(old)
+		if deleted {
+			sql.Touch(row.Addr().Interface(), column, time.Now(), true)
+		} else if index, ok := t.fields[column]; ok {
+			f := row.FieldByIndex(index)
+			f.Set(reflect.Zero(f.Type()))
+		}
+
+		if err = t.put(tx, t.rows(tx), id, row, &old); err != nil {
+			return err
+		}
+
+		n = 1
+		return nil
+	})
+
+	return
+}
+
+// Purge removes a single record from the table, even if it is `sql.SoftDeletable`.
+func (t *Table) Purge(ctx context.Context, id int64) (n int, err error) {
+	err = t.conn.Update(func(tx *bbolt.Tx) error {
+		row, ok, err := t.get(tx, id)
+		if err != nil || !ok {
+			return err
+		}
+
+		for _, column := range t.indexes {
+			if err = t.index(tx, column).Delete(t.indexKey(row, column, id)); err != nil {
+				return err
+			}
+		}
+
+		if err = t.rows(tx).Delete(itob(id)); err != nil {
+			return err
+		}
+
+		n = 1
+		return nil
+	})
+
+	return
+}
+
+// Insert stores the "rec" and returns its primary key.
+// A zero primary key is generated from the bucket's sequence (auto increment)
+// and nil created_at and updated_at columns are set to the current time.
+func (t *Table) Insert(ctx context.Context, rec interface{}) (id int64, err error) {
+	err = t.conn.Update(func(tx *bbolt.Tx) error {
+		id, err = t.insert(tx, rec)
+		return err
+	})
+
+	return
+}
+
+// BatchInsert stores all the "records" in a single transaction
+// and returns their generated IDs, none of them is stored on failure.
+func (t *Table) BatchInsert(ctx context.Context, records []interface{}) ([]int64, error) {
+	ids := make([]int64, 0, len(records))
+	err := t.conn.Update(func(tx *bbolt.Tx) error {
+		for _, rec := range records {
+			id, err := t.insert(tx, rec)
+			if err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (t *Table) insert(tx *bbolt.Tx, rec interface{}) (int64, error) {
+	row, err := t.structValue(rec)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := t.writable(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	id := t.id(row)
+	if id <= 0 {
+		seq, err := rows.NextSequence()
+		if err != nil {
+			return 0, err
+		}
+		id = int64(seq)
+		row.FieldByIndex(t.fields[t.rec.PrimaryKey()]).SetInt(id)
+	} else if rows.Get(itob(id)) != nil {
+		return 0, memory.ErrDuplicate
+	} else if uint64(id) > rows.Sequence() {
+		if err = rows.SetSequence(uint64(id)); err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now()
+	sql.Touch(row.Addr().Interface(), "created_at", now, false)
+	sql.Touch(row.Addr().Interface(), "updated_at", now, false)
+
+	return id, t.put(tx, rows, id, row, nil)
+}
+
+// Update replaces the stored record with the same primary key as "rec".
+// It returns the affected number (0 when the record does not exist or it is soft deleted, otherwise 1).
+// A `sql.Versioned` record with a version different than the stored one
+// results to `sql.ErrConflict`.
+func (t *Table) Update(ctx context.Context, rec interface{}) (n int, err error) {
+	row, err := t.structValue(rec)
+	if err != nil {
+		return 0, err
+	}
+	id := t.id(row)
+	expected, _ := sql.VersionOf(row.Addr().Interface())
+
+	err = t.conn.Update(func(tx *bbolt.Tx) error {
+		old, ok, err := t.get(tx, id)
+		if err != nil || !ok || t.hidden(ctx, old) {
+			return err
+		}
+
+		if err = sql.CheckVersion(old.Addr().Interface(), expected); err != nil {
+			return err
+		}
+
+		// created_at is not part of an update.
+		if index, ok := t.fields["created_at"]; ok {
+			row.FieldByIndex(index).Set(old.FieldByIndex(index))
+		}
+		now := time.Now()
+		sql.Touch(row.Addr().Interface(), "updated_at", now, true)
+		sql.NextVersion(row.Addr().Interface(), old.Addr().Interface(), now)
+
+		if err = t.put(tx, t.rows(tx), id, row, &old); err != nil {
+			return err
+		}
+
+		n = 1
+		return nil
+	})
+
+	return
+}
+
+// PartialUpdate accepts a key-value map to update the record based on the given "id".
+// Values are converted to the column's type, columns out of the `sql.UpdateSchemaOf` the record
+// or inconvertible values result to `sql.ErrUnprocessable`, like on `sql.Repository.PartialUpdate`.
+// The version column of a `sql.Versioned` record is checked like on `Update`.
+func (t *Table) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (n int, err error) {
+	if len(attrs) == 0 {
+		return 0, nil
+	}
+
+	expected, attrs, err := sql.SplitVersion(t.rec, attrs)
+	if err != nil {
+		return 0, err
+	}
+
+	err = t.conn.Update(func(tx *bbolt.Tx) error {
+		old, ok, err := t.get(tx, id)
+		if err != nil || !ok || t.hidden(ctx, old) {
+			return err
+		}
+
+		if err = sql.CheckVersion(old.Addr().Interface(), expected); err != nil {
+			return err
+		}
+
+		row := reflect.New(t.typ).Elem()
+		row.Set(old)
+		for column, value := range attrs {
+			if _, ok := t.updates[column]; !ok || (value == nil && !sql.IsNullable(t.rec, column)) {
+				return sql.ErrUnprocessable
+			}
+
+			if err = t.schema.Set(row.Addr().Interface(), column, value); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		sql.Touch(row.Addr().Interface(), "updated_at", now, true)
+		sql.NextVersion(row.Addr().Interface(), old.Addr().Interface(), now)
+
+		if err = t.put(tx, t.rows(tx), id, row, &old); err != nil {
+			return err
+		}
+
+		n = 1
+		return nil
+	})
+
+	return
+}
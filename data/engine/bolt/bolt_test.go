@@ -0,0 +1,228 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+)
+
+type parityItem struct {
+	ID         int64      `db:"id"`
+	Name       string     `db:"name"`
+	CategoryID int64      `db:"category_id"`
+	Price      float64    `db:"price"`
+	Rank       *int64     `db:"rank"`
+	Version    int64      `db:"version"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+}
+
+func (parityItem) TableName() string        { return "parity_items" }
+func (parityItem) PrimaryKey() string       { return "id" }
+func (parityItem) SortBy() string           { return "rank" }
+func (parityItem) SoftDeleteColumn() string { return "deleted_at" }
+func (parityItem) VersionColumn() string    { return "version" }
+
+func (i parityItem) String() string {
+	rank := "NULL"
+	if i.Rank != nil {
+		rank = fmt.Sprint(*i.Rank)
+	}
+
+	return fmt.Sprintf("{%d %s %d %.2f %s v%d deleted:%t}", i.ID, i.Name, i.CategoryID, i.Price, rank, i.Version, i.DeletedAt != nil)
+}
+
+// parityTable is implemented by both the `memory.Table` and the bolt `Table`.
+type parityTable interface {
+	Count(ctx context.Context) (int64, error)
+	GetByID(ctx context.Context, dest interface{}, id int64) error
+	GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error
+	GetAll(ctx context.Context, dest interface{}) error
+	DeleteByID(ctx context.Context, id int64) (int, error)
+	Restore(ctx context.Context, id int64) (int, error)
+	Purge(ctx context.Context, id int64) (int, error)
+	Insert(ctx context.Context, rec interface{}) (int64, error)
+	Update(ctx context.Context, rec interface{}) (int, error)
+	PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error)
+	ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error)
+}
+
+func rank(n int64) *int64 {
+	return &n
+}
+
+// exercise runs the same operations against the "table" and logs their results.
+func exercise(table parityTable) (log []string) {
+	ctx := context.Background()
+	logf := func(format string, args ...interface{}) {
+		log = append(log, fmt.Sprintf(format, args...))
+	}
+
+	for _, item := range []parityItem{
+		{Name: "a", CategoryID: 1, Price: 5, Rank: rank(2), Version: 1},
+		{Name: "b", CategoryID: 2, Price: 15, Version: 1},
+		{Name: "c", CategoryID: 1, Price: 25, Rank: rank(1), Version: 1},
+		{Name: "d", CategoryID: 2, Price: 35, Rank: rank(2), Version: 1},
+		{Name: "e", CategoryID: 3, Price: 45, Version: 1},
+	} {
+		id, err := table.Insert(ctx, item)
+		logf("insert %s: %d %v", item.Name, id, err)
+	}
+
+	count, err := table.Count(ctx)
+	logf("count: %d %v", count, err)
+
+	var item parityItem
+	err = table.GetByID(ctx, &item, 3)
+	logf("get 3: %v %v", item, err)
+	err = table.GetByID(ctx, &parityItem{}, 99)
+	logf("get 99: %v", err)
+
+	var list []parityItem
+	err = table.GetAll(ctx, &list)
+	logf("all: %v %v", list, err)
+
+	item = parityItem{}
+	err = table.GetByAttrs(ctx, &item, map[string]interface{}{"category_id": 2})
+	logf("by category 2: %v %v", item, err)
+
+	// optimistic locking.
+	item = parityItem{ID: 3, Name: "c2", CategoryID: 1, Price: 26, Version: 1}
+	n, err := table.Update(ctx, item)
+	logf("update 3: %d %v", n, err)
+	n, err = table.Update(ctx, item)
+	logf("update 3 again with version 1: %d %v", n, err)
+	n, err = table.Update(ctx, parityItem{ID: 99, Name: "x"})
+	logf("update 99: %d %v", n, err)
+	n, err = table.PartialUpdate(ctx, 4, map[string]interface{}{"rank": nil, "price": 40, "version": 1})
+	logf("partial update 4: %d %v", n, err)
+	n, err = table.PartialUpdate(ctx, 4, map[string]interface{}{"price": 41, "version": 1})
+	logf("partial update 4 again with version 1: %d %v", n, err)
+	n, err = table.PartialUpdate(ctx, 4, map[string]interface{}{"unknown": 1})
+	logf("partial update 4 unknown column: %d %v", n, err)
+	n, err = table.PartialUpdate(ctx, 4, map[string]interface{}{"created_at": "2020-01-01T00:00:00Z"})
+	logf("partial update 4 created_at: %d %v", n, err)
+
+	// soft delete.
+	n, err = table.DeleteByID(ctx, 1)
+	logf("delete 1: %d %v", n, err)
+	count, err = table.Count(ctx)
+	logf("count: %d %v", count, err)
+	err = table.GetByID(ctx, &parityItem{}, 1)
+	logf("get deleted 1: %v", err)
+	item = parityItem{}
+	err = table.GetByID(sql.WithDeleted(ctx), &item, 1)
+	logf("get deleted 1 with deleted: %v %v", item, err)
+	n, err = table.Update(ctx, parityItem{ID: 1, Name: "a2", Version: 1})
+	logf("update deleted 1: %d %v", n, err)
+	n, err = table.PartialUpdate(ctx, 1, map[string]interface{}{"name": "a2", "version": 1})
+	logf("partial update deleted 1: %d %v", n, err)
+	n, err = table.Restore(ctx, 1)
+	logf("restore 1: %d %v", n, err)
+	n, err = table.Purge(ctx, 5)
+	logf("purge 5: %d %v", n, err)
+	n, err = table.Purge(ctx, 5)
+	logf("purge 5 again: %d %v", n, err)
+
+	// lists.
+	for _, query := range []string{
+		"limit=2",
+		"limit=2&order=desc",
+		"by=price&limit=2",
+		"by=price&order=desc&limit=2",
+		"price[gte]=20&limit=2",
+		"category_id[in]=1,2&limit=2",
+		"name[like]=C2",
+		"limit=2&offset=1",
+		"price[gt]=1000",
+	} {
+		q, _ := url.ParseQuery(query)
+		opts := sql.ParseListOptions(q)
+		for pages := 0; pages < 5; pages++ {
+			var list []parityItem
+			page, err := table.ListPage(ctx, &list, opts)
+			logf("list %s: %v next:%t %v", query, list, page.NextCursor != "", err)
+			if page.NextCursor == "" || opts.Offset > 0 {
+				break
+			}
+			opts.Cursor = page.NextCursor
+		}
+	}
+
+	return
+}
+
+func TestParity(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "parity.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	expected := exercise(memory.NewTable(new(parityItem)))
+	got := exercise(db.Table(new(parityItem)))
+
+	if !reflect.DeepEqual(got, expected) {
+		for i := range expected {
+			if i >= len(got) || got[i] != expected[i] {
+				t.Errorf("[%d]\nmemory: %s\nbolt:   %s", i, expected[i], got[i])
+			}
+		}
+		t.FailNow()
+	}
+}
+
+// TestPartialUpdateSchema checks that all engines accept only the `sql.UpdateSchemaOf` columns on a partial update.
+func TestPartialUpdateSchema(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "schema.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	memoryTable, boltTable := memory.NewTable(new(parityItem)), db.Table(new(parityItem))
+	// the sql engine rejects the columns before it queries the database.
+	sqlRepo := sql.NewRepository(nil, new(parityItem))
+	schema := sql.UpdateSchemaOf(new(parityItem))
+
+	engines := map[string]func(id int64, attrs map[string]interface{}) (int, error){
+		"memory": func(id int64, attrs map[string]interface{}) (int, error) {
+			return memoryTable.PartialUpdate(ctx, id, attrs)
+		},
+		"bolt": func(id int64, attrs map[string]interface{}) (int, error) {
+			return boltTable.PartialUpdate(ctx, id, attrs)
+		},
+		"sql": func(id int64, attrs map[string]interface{}) (int, error) {
+			return sqlRepo.PartialUpdate(ctx, id, schema, attrs)
+		},
+	}
+
+	for _, table := range []parityTable{memoryTable, boltTable} {
+		if _, err = table.Insert(ctx, parityItem{Name: "a", Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, attrs := range []map[string]interface{}{
+		{"id": 2},
+		{"created_at": "2020-01-01T00:00:00Z"},
+		{"updated_at": "2020-01-01T00:00:00Z"},
+		{"deleted_at": nil},
+		{"name": "b", "created_at": "2020-01-01T00:00:00Z"},
+	} {
+		for name, partialUpdate := range engines {
+			if n, err := partialUpdate(1, attrs); n != 0 || err != sql.ErrUnprocessable {
+				t.Fatalf("%s: %v: expected %v but got %d %v", name, attrs, sql.ErrUnprocessable, n, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package bolt
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// itob returns the 8-byte big endian representation of "id",
+// keys of the rows bucket sort by the primary key.
+func itob(id int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
+
+func btoi(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// encode marshals the columns of the "row" struct to a JSON object keyed by column name,
+// unlike the models' JSON form every `db` field is kept, e.g. users' hashpass.
+func (t *Table) encode(row reflect.Value) ([]byte, error) {
+	obj := make(map[string]json.RawMessage, len(t.fields))
+	for column, index := range t.fields {
+		b, err := json.Marshal(row.FieldByIndex(index).Interface())
+		if err != nil {
+			return nil, err
+		}
+		obj[column] = b
+	}
+
+	return json.Marshal(obj)
+}
+
+// decode unmarshals data generated by `encode` to a new record of the table's struct.
+func (t *Table) decode(data []byte) (reflect.Value, error) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return reflect.Value{}, err
+	}
+
+	row := reflect.New(t.typ).Elem()
+	for column, raw := range obj {
+		index, ok := t.fields[column]
+		if !ok {
+			continue // dropped column.
+		}
+
+		if err := json.Unmarshal(raw, row.FieldByIndex(index).Addr().Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+
+	return row, nil
+}
+
+// indexKey returns the key of the "row" on the index of the "column":
+// its order preserving sort value followed by the primary key.
+// NULL values come first, like on MySQL.
+func (t *Table) indexKey(row reflect.Value, column string, id int64) []byte {
+	return append(sortValue(row.FieldByIndex(t.fields[column])), itob(id)...)
+}
+
+func sortValue(f reflect.Value) []byte {
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return []byte{0}
+		}
+		f = f.Elem()
+	}
+
+	if valuer, ok := f.Interface().(driver.Valuer); ok && f.Kind() == reflect.Struct {
+		// e.g. sql.NullString.
+		v, err := valuer.Value()
+		if err != nil || v == nil {
+			return []byte{0}
+		}
+		f = reflect.ValueOf(v)
+	}
+
+	key := []byte{1}
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return append(key, itob(f.Int()^math.MinInt64)...)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return append(key, itob(int64(f.Uint()))...)
+	case reflect.Float32, reflect.Float64:
+		bits := math.Float64bits(f.Float())
+		if bits&(1<<63) != 0 {
+			bits = ^bits
+		} else {
+			bits |= 1 << 63
+		}
+		return append(key, itob(int64(bits))...)
+	case reflect.Bool:
+		if f.Bool() {
+			return append(key, 1)
+		}
+		return append(key, 0)
+	case reflect.String:
+		// case-insensitive like the default MySQL collation, terminated to keep prefixes first.
+		return append(append(key, strings.ToLower(f.String())...), 0)
+	case reflect.Struct:
+		if tm, ok := f.Interface().(time.Time); ok {
+			return append(key, itob(tm.UnixNano()^math.MinInt64)...)
+		}
+	}
+
+	return key
+}
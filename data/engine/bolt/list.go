@@ -0,0 +1,188 @@
+package bolt
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+
+	"go.etcd.io/bbolt"
+)
+
+// List binds one or more records to the "dest" based on the "opts", see `sql.Repository.List`.
+// Lists sorted by the primary key or by one of the `SortBy` columns,
+// without filters, are read straight from the index with offset and limit.
+// Any other list is evaluated by the memory engine over all the records of the table.
+func (t *Table) List(ctx context.Context, dest interface{}, opts sql.ListOptions) error {
+	_, err := t.list(ctx, dest, opts)
+	return err
+}
+
+// ListPage same as `List` but it returns a `sql.Page` of the "dest"
+// and the cursor of the next set of records, see `sql.Repository.ListPage`.
+func (t *Table) ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error) {
+	opts, err := t.list(ctx, dest, opts)
+	if err != nil {
+		return sql.Page{}, err
+	}
+
+	return sql.Page{Items: dest, NextCursor: sql.NextCursor(dest, opts)}, nil
+}
+
+func (t *Table) list(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.ListOptions, error) {
+	if opts.Table != "" && opts.Table != t.rec.TableName() {
+		return opts, sql.ErrUnprocessable
+	}
+	opts.Table = t.rec.TableName()
+	opts.PrimaryKey = t.rec.PrimaryKey()
+
+	if opts.OrderByColumn == "" {
+		if b, ok := t.rec.(sql.Sorted); ok {
+			opts.OrderByColumn = b.SortBy()
+		}
+	}
+
+	column := strings.TrimSpace(opts.OrderByColumn)
+	indexed := column == "" || column == opts.PrimaryKey
+	for _, idx := range t.indexes {
+		indexed = indexed || idx == column
+	}
+
+	if !indexed || opts.Filter != nil || opts.WhereColumn != "" || opts.Cursor != "" {
+		return opts, t.evaluate(ctx, dest, opts)
+	}
+
+	if opts.IncludeDeleted {
+		ctx = sql.WithDeleted(ctx)
+	}
+
+	var rows []reflect.Value
+	err := t.conn.View(func(tx *bbolt.Tx) error {
+		var (
+			b        *bbolt.Bucket
+			fromKeys = false // the index keys end with the primary key, the rows keys are the primary key.
+		)
+
+		if column == "" || column == opts.PrimaryKey {
+			b = t.rows(tx)
+		} else {
+			b = t.index(tx, column)
+			fromKeys = true
+		}
+		if b == nil {
+			return nil
+		}
+
+		desc := sql.ParseOrder(opts.Order) == "DESC"
+		c := b.Cursor()
+		first, next := c.First, c.Next
+		if desc {
+			first, next = c.Last, c.Prev
+		}
+
+		skip := opts.Offset
+		soft := sql.SoftDeleteColumnOf(t.rec) != ""
+		for k, v := first(); k != nil; k, v = next() {
+			if skip > 0 && !soft {
+				skip--
+				continue
+			}
+
+			if fromKeys {
+				id := btoi(k[len(k)-8:])
+				if v = t.rows(tx).Get(itob(id)); v == nil {
+					continue
+				}
+			}
+
+			row, err := t.decode(v)
+			if err != nil {
+				return err
+			}
+
+			if soft {
+				// the offset counts only the visible rows.
+				if t.hidden(ctx, row) {
+					continue
+				}
+				if skip > 0 {
+					skip--
+					continue
+				}
+			}
+			rows = append(rows, row)
+
+			if opts.Limit > 0 && uint64(len(rows)) == opts.Limit {
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return opts, err
+	}
+
+	return opts, t.bindAll(dest, rows)
+}
+
+// evaluate loads the records on a `memory.Table` and lists them from there.
+func (t *Table) evaluate(ctx context.Context, dest interface{}, opts sql.ListOptions) error {
+	tmp := memory.NewTable(t.rec)
+
+	var insertErr error
+	err := t.conn.View(func(tx *bbolt.Tx) error {
+		return t.each(tx, func(row reflect.Value) bool {
+			_, insertErr = tmp.Insert(ctx, row.Addr().Interface())
+			return insertErr == nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if insertErr != nil {
+		return insertErr
+	}
+
+	return tmp.List(ctx, dest, opts)
+}
+
+// Reindex rebuilds the index buckets of the table,
+// e.g. after a change of the record's `SortBy` columns.
+func (t *Table) Reindex() error {
+	return t.conn.Update(func(tx *bbolt.Tx) error {
+		rows, err := t.writable(tx)
+		if err != nil {
+			return err
+		}
+
+		table := tx.Bucket(t.name)
+		for _, column := range t.indexes {
+			name := []byte(indexPrefix + column)
+			if err = table.DeleteBucket(name); err != nil {
+				return err
+			}
+
+			idx, err := table.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+
+			c := rows.Cursor()
+			for k, v := c.First(); k != nil; k, v = c.Next() {
+				row, err := t.decode(v)
+				if err != nil {
+					return err
+				}
+
+				if err = idx.Put(t.indexKey(row, column, btoi(k)), nil); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"morshed/data/engine/sql"
-	"morshed/data/models"
 
 	"github.com/mailgun/groupcache/v2"
 )
@@ -18,32 +18,46 @@ type Service interface {
 	RecordInfo() sql.Record
 	GetByID(ctx context.Context, dest interface{}, id int64) error
 	List(ctx context.Context, dest interface{}, opts sql.ListOptions) error
+	ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error)
 }
 
 // Cache is a simple structure which holds the groupcache and the database service, exposes
 // `GetByID` and `List` which returns cached (or stores new) items.
 type Cache struct {
 	service Service
-	maxAge  time.Duration
+	model   Model
 	group   *groupcache.Group
 }
 
 // Size default size to use on groupcache, defaults to 3MB.
-var Size int64 = 3 << (10 * 3)
+var Size int64 = 3 << (10 * 2)
 
 // New returns a new cache service which exposes `GetByID` and `List` methods to work with.
 // The "name" should be unique, "maxAge" for cache expiration.
+// A zero "maxAge" uses the one of the service's record `Model`, see `Register`,
+// the size of the cache is the one of the `Model`.
 func New(service Service, name string, maxAge time.Duration) *Cache {
 	c := new(Cache)
 	c.service = service
-	c.maxAge = maxAge
-	c.group = groupcache.NewGroup(name, Size, c)
+	c.model = ModelOf(service.RecordInfo())
+	if maxAge > 0 {
+		c.model.MaxAge = maxAge
+	}
+	c.group = groupcache.NewGroup(name, c.model.Size, c)
 	return c
 }
 
+// Name returns the name of the cache.
+func (c *Cache) Name() string {
+	return c.group.Name()
+}
+
 const (
 	prefixID   = "#"
 	prefixList = "["
+	prefixGen  = "@"
+	// keyGeneration is the key of the generation of the cached lists, see `Invalidate`.
+	keyGeneration = prefixGen + "lists"
 )
 
 // Get implements the groupcache.Getter interface.
@@ -58,6 +72,10 @@ func (c *Cache) Get(ctx context.Context, key string, dest groupcache.Sink) error
 	prefix := key[0:1]
 	key = key[1:]
 	switch prefix {
+	case prefixGen:
+		// A new generation of the lists, the previous one has been removed.
+		v = time.Now().UnixNano()
+
 	case prefixID:
 		// Get by ID.
 		id, err := strconv.ParseInt(key, 10, 64)
@@ -65,13 +83,7 @@ func (c *Cache) Get(ctx context.Context, key string, dest groupcache.Sink) error
 			return err
 		}
 
-		switch c.service.RecordInfo().(type) {
-		case *models.User:
-			v = new(models.User)
-		case *models.Product:
-			v = new(models.Product)
-		}
-
+		v = c.model.New()
 		err = c.service.GetByID(ctx, v, id)
 		if err != nil {
 			return err
@@ -79,23 +91,22 @@ func (c *Cache) Get(ctx context.Context, key string, dest groupcache.Sink) error
 
 	case prefixList:
 		// Get a set of records, list.
+		if i := strings.IndexByte(key, ':'); i >= 0 {
+			key = key[i+1:] // skip the generation.
+		}
+
 		q, err := url.ParseQuery(key)
 		if err != nil {
 			return err
 		}
 		opts := sql.ParseListOptions(q)
 
-		switch c.service.RecordInfo().(type) {
-		case *models.User:
-			v = new(models.Users)
-		case *models.Product:
-			v = new(models.Products)
-		}
-
-		err = c.service.List(ctx, v, opts)
+		v = c.model.NewList()
+		page, err := c.service.ListPage(ctx, v, opts)
 		if err != nil {
 			return err
 		}
+		v = page
 
 	default:
 		return sql.ErrUnprocessable
@@ -106,7 +117,7 @@ func (c *Cache) Get(ctx context.Context, key string, dest groupcache.Sink) error
 		return err
 	}
 
-	return dest.SetBytes(b, time.Now().Add(c.maxAge))
+	return dest.SetBytes(b, time.Now().Add(c.model.MaxAge))
 }
 
 // GetByID binds an item to "dest" an item based on its "id".
@@ -115,6 +126,70 @@ func (c *Cache) GetByID(ctx context.Context, id string, dest *[]byte) error {
 }
 
 // List binds item to "dest" based on the "rawQuery" of `url.Values` for `ListOptions`.
+// The result is a JSON `sql.Page`, its "next_cursor" can be passed as ?cursor= to fetch the next items.
 func (c *Cache) List(ctx context.Context, rawQuery string, dest *[]byte) error {
-	return c.group.Get(ctx, prefixList+rawQuery, groupcache.AllocatingByteSliceSink(dest))
+	var generation string
+	if err := c.group.Get(ctx, keyGeneration, groupcache.StringSink(&generation)); err != nil {
+		return err
+	}
+
+	key := prefixList + generation + ":" + rawQuery
+	return c.group.Get(ctx, key, groupcache.AllocatingByteSliceSink(dest))
+}
+
+// Invalidate evicts the records of the "ids" and all the cached lists,
+// it should be called after the records of the table are changed.
+// The lists are never evicted one by one, their keys carry a generation number
+// which is cached like the records, so all the peers share it. It is removed here
+// and the next `List` calls read through the service with a new generation.
+func (c *Cache) Invalidate(ctx context.Context, ids ...int64) error {
+	err := c.group.Remove(ctx, keyGeneration)
+	for _, id := range ids {
+		if removeErr := c.group.Remove(ctx, prefixID+strconv.FormatInt(id, 10)); removeErr != nil {
+			err = removeErr
+		}
+	}
+
+	return err
+}
+
+// Stats holds the statistics of a `Cache` on this process.
+type Stats struct {
+	Name           string `json:"name"`
+	Gets           int64  `json:"gets"`            // any get, including the ones of the peers.
+	Hits           int64  `json:"hits"`            // gets served by the local main or hot cache.
+	Misses         int64  `json:"misses"`          // gets which were loaded, by a peer or the service.
+	PeerLoads      int64  `json:"peer_loads"`      // misses served by the peer which owns the key.
+	PeerErrors     int64  `json:"peer_errors"`     // misses the owner peer failed to serve.
+	LocalLoads     int64  `json:"local_loads"`     // misses served by the service.
+	LocalLoadErrs  int64  `json:"local_load_errs"` // misses the service failed to serve.
+	ServerRequests int64  `json:"server_requests"` // gets of the peers served by this process.
+	MainBytes      int64  `json:"main_bytes"`      // the size of the keys this process owns.
+	MainItems      int64  `json:"main_items"`
+	HotBytes       int64  `json:"hot_bytes"` // the size of the popular keys of the other peers.
+	HotItems       int64  `json:"hot_items"`
+	Evictions      int64  `json:"evictions"`
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache) Stats() Stats {
+	s := c.group.Stats
+	main, hot := c.group.CacheStats(groupcache.MainCache), c.group.CacheStats(groupcache.HotCache)
+
+	return Stats{
+		Name:           c.Name(),
+		Gets:           s.Gets.Get(),
+		Hits:           s.CacheHits.Get(),
+		Misses:         s.Loads.Get(),
+		PeerLoads:      s.PeerLoads.Get(),
+		PeerErrors:     s.PeerErrors.Get(),
+		LocalLoads:     s.LocalLoads.Get(),
+		LocalLoadErrs:  s.LocalLoadErrs.Get(),
+		ServerRequests: s.ServerRequests.Get(),
+		MainBytes:      main.Bytes,
+		MainItems:      main.Items,
+		HotBytes:       hot.Bytes,
+		HotItems:       hot.Items,
+		Evictions:      main.Evictions + hot.Evictions,
+	}
 }
@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"morshed/data/engine/sql"
+
+	"github.com/mailgun/groupcache/v2"
+)
+
+type cachedItem struct {
+	ID   int64  `db:"id" json:"id"`
+	Name string `db:"name" json:"name"`
+}
+
+func (cachedItem) TableName() string  { return "cached_items" }
+func (cachedItem) PrimaryKey() string { return "id" }
+
+// unregisteredItem has no `Model`.
+type unregisteredItem struct {
+	cachedItem
+}
+
+// itemService is a `Service` of the "items" which counts its loads.
+type itemService struct {
+	rec sql.Record
+
+	mu    sync.Mutex
+	items map[int64]string
+	gets  map[int64]int
+	lists int
+}
+
+func newItemService(rec sql.Record, items map[int64]string) *itemService {
+	return &itemService{rec: rec, items: items, gets: make(map[int64]int)}
+}
+
+func (s *itemService) RecordInfo() sql.Record {
+	return s.rec
+}
+
+func (s *itemService) set(id int64, name string) {
+	s.mu.Lock()
+	s.items[id] = name
+	s.mu.Unlock()
+}
+
+func (s *itemService) loads(id int64) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets[id]
+}
+
+func (s *itemService) listLoads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lists
+}
+
+func (s *itemService) GetByID(ctx context.Context, dest interface{}, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gets[id]++
+	name, ok := s.items[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	switch dest := dest.(type) {
+	case *cachedItem:
+		*dest = cachedItem{ID: id, Name: name}
+	case *unregisteredItem:
+		dest.cachedItem = cachedItem{ID: id, Name: name}
+	}
+	return nil
+}
+
+func (s *itemService) List(ctx context.Context, dest interface{}, opts sql.ListOptions) error {
+	_, err := s.ListPage(ctx, dest, opts)
+	return err
+}
+
+func (s *itemService) ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lists++
+	list := dest.(*[]*cachedItem)
+	for id := int64(1); id <= int64(len(s.items)); id++ {
+		*list = append(*list, &cachedItem{ID: id, Name: s.items[id]})
+	}
+
+	return sql.Page{Items: dest}, nil
+}
+
+func init() {
+	Register(new(cachedItem), Model{MaxAge: time.Hour, Size: 1 << 10})
+}
+
+func TestModelOf(t *testing.T) {
+	m := ModelOf(new(cachedItem))
+	if m.MaxAge != time.Hour || m.Size != 1<<10 {
+		t.Fatalf("expected the registered budget but got max age %s and size %d", m.MaxAge, m.Size)
+	}
+	if got, ok := m.New().(*cachedItem); !ok || got == nil {
+		t.Fatalf("expected a new *cachedItem but got %T", m.New())
+	}
+	if _, ok := m.NewList().(*[]*cachedItem); !ok {
+		t.Fatalf("expected a new *[]*cachedItem but got %T", m.NewList())
+	}
+
+	m = ModelOf(unregisteredItem{})
+	if m.MaxAge != DefaultMaxAge || m.Size != Size {
+		t.Fatalf("expected the default budget but got max age %s and size %d", m.MaxAge, m.Size)
+	}
+	if _, ok := m.New().(*unregisteredItem); !ok {
+		t.Fatalf("expected a new *unregisteredItem but got %T", m.New())
+	}
+}
+
+func getItem(t *testing.T, c *Cache, id string) cachedItem {
+	t.Helper()
+
+	var b []byte
+	if err := c.GetByID(context.Background(), id, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	var item cachedItem
+	if err := json.Unmarshal(b, &item); err != nil {
+		t.Fatal(err)
+	}
+
+	return item
+}
+
+func TestCacheGetByID(t *testing.T) {
+	service := newItemService(new(cachedItem), map[int64]string{1: "a", 2: "b"})
+	c := New(service, "test-get-by-id", 0)
+	if c.model.Size != 1<<10 || c.model.MaxAge != time.Hour {
+		t.Fatalf("expected the budget of the registered model but got size %d and max age %s", c.model.Size, c.model.MaxAge)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got := getItem(t, c, "1"); got.Name != "a" {
+			t.Fatalf("expected item a but got %#v", got)
+		}
+	}
+	if loads := service.loads(1); loads != 1 {
+		t.Fatalf("expected a single load but got %d", loads)
+	}
+
+	service.set(1, "a2")
+	if got := getItem(t, c, "1"); got.Name != "a" {
+		t.Fatalf("expected the cached item a before the invalidation but got %#v", got)
+	}
+
+	if err := c.Invalidate(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := getItem(t, c, "1"); got.Name != "a2" {
+		t.Fatalf("expected the reloaded item a2 but got %#v", got)
+	}
+	if loads := service.loads(1); loads != 2 {
+		t.Fatalf("expected the invalidated item to be loaded again but got %d loads", loads)
+	}
+	if loads := service.loads(2); loads != 0 {
+		t.Fatalf("expected no loads of item 2 but got %d", loads)
+	}
+
+	var b []byte
+	if err := c.GetByID(context.Background(), "3", &b); err == nil {
+		t.Fatal("expected an error for a missing item")
+	}
+}
+
+func TestCacheUnregistered(t *testing.T) {
+	service := newItemService(unregisteredItem{}, map[int64]string{1: "a"})
+	c := New(service, "test-unregistered", time.Second)
+
+	if got := getItem(t, c, "1"); got.Name != "a" {
+		t.Fatalf("expected item a but got %#v", got)
+	}
+	if c.model.MaxAge != time.Second || c.model.Size != Size {
+		t.Fatalf("expected the max age of New and the default size but got %s and %d", c.model.MaxAge, c.model.Size)
+	}
+}
+
+func listItems(t *testing.T, c *Cache, rawQuery string) []string {
+	t.Helper()
+
+	var b []byte
+	if err := c.List(context.Background(), rawQuery, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	var page struct {
+		Items []cachedItem `json:"items"`
+	}
+	if err := json.Unmarshal(b, &page); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(page.Items))
+	for _, item := range page.Items {
+		names = append(names, item.Name)
+	}
+
+	return names
+}
+
+func TestCacheListGeneration(t *testing.T) {
+	service := newItemService(new(cachedItem), map[int64]string{1: "a", 2: "b"})
+	c := New(service, "test-list-generation", 0)
+
+	for i := 0; i < 3; i++ {
+		if got := listItems(t, c, "limit=10"); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Fatalf("expected items [a b] but got %v", got)
+		}
+	}
+	if loads := service.listLoads(); loads != 1 {
+		t.Fatalf("expected a single list load but got %d", loads)
+	}
+
+	// a record write invalidates every list of the table.
+	service.set(3, "c")
+	if err := c.Invalidate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := listItems(t, c, "limit=10"); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+		t.Fatalf("expected the reloaded items [a b c] but got %v", got)
+	}
+	if loads := service.listLoads(); loads != 2 {
+		t.Fatalf("expected the list to be loaded again but got %d loads", loads)
+	}
+}
+
+func TestCacheGetKeys(t *testing.T) {
+	c := New(newItemService(new(cachedItem), map[int64]string{}), "test-get-keys", 0)
+
+	for _, key := range []string{"", "#", "?1", "x1"} {
+		var b []byte
+		if err := c.Get(context.Background(), key, groupcache.AllocatingByteSliceSink(&b)); err != sql.ErrUnprocessable {
+			t.Fatalf("%q: expected error %v but got %v", key, sql.ErrUnprocessable, err)
+		}
+	}
+}
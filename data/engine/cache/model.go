@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"morshed/data/engine/sql"
+)
+
+// Model holds the cache settings of a record type, see `Register`.
+type Model struct {
+	// New returns a pointer to a new record, e.g. new(models.Product).
+	// Defaults to a new zero value of the record's type.
+	New func() interface{}
+	// NewList returns a pointer to a new slice of records, e.g. new(models.Products).
+	// Defaults to a new slice of pointers to the record's type.
+	NewList func() interface{}
+	// MaxAge is the expiration of the cached records, defaults to `DefaultMaxAge`.
+	MaxAge time.Duration
+	// Size is the maximum size in bytes of the cached records, defaults to `Size`.
+	Size int64
+}
+
+// DefaultMaxAge is the default expiration of the records of a `Model`.
+var DefaultMaxAge = time.Minute
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[reflect.Type]Model)
+)
+
+// Register sets the cache settings of the "rec" record type,
+// records which are not registered are cached with the defaults.
+// It should be called before the `New` cache of the record is created.
+func Register(rec sql.Record, m Model) {
+	registryMu.Lock()
+	registry[recordType(rec)] = m
+	registryMu.Unlock()
+}
+
+// ModelOf returns the cache settings of the "rec" record type, the zero fields are set to their defaults.
+func ModelOf(rec sql.Record) Model {
+	typ := recordType(rec)
+
+	registryMu.RLock()
+	m := registry[typ]
+	registryMu.RUnlock()
+
+	if m.New == nil {
+		m.New = func() interface{} {
+			return reflect.New(typ).Interface()
+		}
+	}
+	if m.NewList == nil {
+		m.NewList = func() interface{} {
+			return reflect.New(reflect.SliceOf(reflect.PtrTo(typ))).Interface()
+		}
+	}
+	if m.MaxAge <= 0 {
+		m.MaxAge = DefaultMaxAge
+	}
+	if m.Size <= 0 {
+		m.Size = Size
+	}
+
+	return m
+}
+
+func recordType(rec sql.Record) reflect.Type {
+	typ := reflect.TypeOf(rec)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return typ
+}
@@ -0,0 +1,500 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mailgun/groupcache/v2"
+)
+
+// Discovery returns the base URLs of the processes which share the caches,
+// e.g. "http://10.0.0.2:8080", see `Pool`.
+type Discovery interface {
+	Peers(ctx context.Context) ([]string, error)
+}
+
+// Registrar can be optionally implemented by a `Discovery` which
+// the processes join themselves, e.g. `FilePeers`.
+type Registrar interface {
+	Register(ctx context.Context, self string) error
+	Deregister(ctx context.Context, self string) error
+}
+
+// StaticPeers is a fixed list of peers.
+type StaticPeers []string
+
+// Peers returns the list.
+func (p StaticPeers) Peers(context.Context) ([]string, error) {
+	return p, nil
+}
+
+// DNSPeers resolves the peers from the addresses of a host name,
+// e.g. a headless service of Kubernetes or a Docker compose service.
+type DNSPeers struct {
+	Host   string
+	Port   string // the port the processes listen on, all of them use the same one.
+	Scheme string // defaults to "http".
+}
+
+// Peers returns a URL of each address of the host.
+func (p DNSPeers) Peers(ctx context.Context) ([]string, error) {
+	addrs, err := net.DefaultResolver.LookupHost(ctx, p.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := p.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	peers := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		host := addr
+		if p.Port != "" {
+			host = net.JoinHostPort(addr, p.Port)
+		}
+		peers = append(peers, scheme+"://"+host)
+	}
+
+	return peers, nil
+}
+
+// FilePeers reads the peers from a membership file, one URL per line,
+// blank lines and lines starting with '#' are skipped.
+// The processes add and remove themselves to the file, so it should be on a disk they all share.
+type FilePeers struct {
+	Path string
+}
+
+// Peers returns the URLs of the file, no peers if the file does not exist yet.
+func (p FilePeers) Peers(context.Context) ([]string, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var peers []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		peers = append(peers, line)
+	}
+
+	return peers, scanner.Err()
+}
+
+// Register appends "self" to the file, if it is not already there.
+func (p FilePeers) Register(ctx context.Context, self string) error {
+	peers, err := p.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, peer := range peers {
+		if normalizePeer(peer) == self {
+			return nil
+		}
+	}
+
+	f, err := os.OpenFile(p.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(f, self)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Deregister removes "self" from the file.
+// The file is replaced at once, so the other processes never read a partial one.
+func (p FilePeers) Deregister(ctx context.Context, self string) error {
+	peers, err := p.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, peer := range peers {
+		if normalizePeer(peer) != self {
+			b.WriteString(peer)
+			b.WriteByte('\n')
+		}
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(p.Path), filepath.Base(p.Path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.WriteString(b.String()); err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p.Path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// PoolOptions holds the settings of a `Pool`, all fields are optional.
+type PoolOptions struct {
+	// BasePath is the path the peers serve the caches on, defaults to "/_groupcache/".
+	BasePath string
+	// Replicas is the number of keys of each peer on the consistent hash, defaults to 50.
+	Replicas int
+	// Discovery returns the peers, the pool is made of the process itself if nil.
+	Discovery Discovery
+	// RefreshInterval is how often the peers are discovered again, defaults to 10 seconds.
+	RefreshInterval time.Duration
+	// Secret is shared by the peers, they send it on the `SecretHeader` of their requests.
+	// If empty, the handler only serves requests from the addresses of the peers.
+	Secret string
+}
+
+// SecretHeader is the request header which carries the `PoolOptions.Secret`.
+const SecretHeader = "X-Cache-Secret"
+
+// DefaultBasePath is the default path of the `Pool` handler.
+const DefaultBasePath = "/_groupcache/"
+
+// Pool shares the caches of this process with its peers, each key is owned by
+// a single peer of a consistent hash and the other peers ask the owner for it.
+// There is only one pool for each process, it should be created before any cache is used.
+type Pool struct {
+	self     string
+	basePath string
+	http     *groupcache.HTTPPool
+
+	secret string
+
+	mu    sync.RWMutex
+	peers []string
+	addrs map[string]struct{}   // the IP addresses of the peers, see `Authorized`.
+	stats map[string]*PeerStats // by peer.
+
+	discovery Discovery
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// NewPool returns the pool of the process which serves its handler on the "self" base URL,
+// e.g. "http://10.0.0.1:8080". It discovers the peers right away and then every
+// `PoolOptions.RefreshInterval` until `Close`. If the discovery is a `Registrar` the process registers itself.
+func NewPool(self string, opts PoolOptions) (*Pool, error) {
+	if u, err := url.Parse(normalizePeer(self)); err != nil || u.Host == "" {
+		return nil, fmt.Errorf("cache: invalid peer URL %q", self)
+	}
+
+	if opts.BasePath == "" {
+		opts.BasePath = DefaultBasePath
+	}
+	if opts.RefreshInterval <= 0 {
+		opts.RefreshInterval = 10 * time.Second
+	}
+	if opts.Discovery == nil {
+		opts.Discovery = StaticPeers(nil)
+	}
+
+	p := &Pool{
+		self:      normalizePeer(self),
+		basePath:  opts.BasePath,
+		secret:    opts.Secret,
+		stats:     make(map[string]*PeerStats),
+		discovery: opts.Discovery,
+		done:      make(chan struct{}),
+	}
+
+	p.http = groupcache.NewHTTPPoolOpts(p.self, &groupcache.HTTPPoolOptions{
+		BasePath: opts.BasePath,
+		Replicas: opts.Replicas,
+		Transport: func(context.Context) http.RoundTripper {
+			return countingTransport{pool: p, next: http.DefaultTransport}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	if r, ok := opts.Discovery.(Registrar); ok {
+		if err := r.Register(ctx, p.self); err != nil {
+			cancel()
+			return nil, fmt.Errorf("cache: register peer %q: %w", p.self, err)
+		}
+	}
+
+	if err := p.refresh(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("cache: discover peers: %w", err)
+	}
+
+	go p.loop(ctx, opts.RefreshInterval)
+	return p, nil
+}
+
+// loop discovers the peers every "interval", a failed discovery keeps the previous peers.
+func (p *Pool) loop(ctx context.Context, interval time.Duration) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = p.refresh(ctx)
+		}
+	}
+}
+
+func (p *Pool) refresh(ctx context.Context) error {
+	peers, err := p.discovery.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.Set(peers...)
+	return nil
+}
+
+// Set replaces the peers of the pool, the process itself is always one of them.
+// The host names of the peers are resolved to the addresses `Authorized` accepts.
+func (p *Pool) Set(peers ...string) {
+	seen := map[string]bool{p.self: true}
+	list := []string{p.self}
+	for _, peer := range peers {
+		if peer = normalizePeer(peer); peer != "" && !seen[peer] {
+			seen[peer] = true
+			list = append(list, peer)
+		}
+	}
+	sort.Strings(list)
+
+	var addrs map[string]struct{}
+	if p.secret == "" {
+		addrs = resolvePeers(list)
+	}
+
+	p.mu.Lock()
+	p.peers = list
+	p.addrs = addrs
+	p.mu.Unlock()
+
+	p.http.Set(list...)
+}
+
+// Self returns the base URL of the process.
+func (p *Pool) Self() string {
+	return p.self
+}
+
+// Peers returns the base URLs of the peers, including the process itself.
+func (p *Pool) Peers() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]string(nil), p.peers...)
+}
+
+// BasePath returns the path the pool serves the caches on, see `ServeHTTP`.
+func (p *Pool) BasePath() string {
+	return p.basePath
+}
+
+// ServeHTTP serves the keys this process owns to the peers,
+// requests which are not `Authorized` are forbidden.
+func (p *Pool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !p.Authorized(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	p.http.ServeHTTP(w, r)
+}
+
+// Authorized reports whether "r" is a request of a peer: it carries the `PoolOptions.Secret`
+// or, without a secret, it comes from the address of one of the peers.
+func (p *Pool) Authorized(r *http.Request) bool {
+	if p.secret != "" {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get(SecretHeader)), []byte(p.secret)) == 1
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	p.mu.RLock()
+	_, ok := p.addrs[canonicalIP(host)]
+	p.mu.RUnlock()
+	return ok
+}
+
+// Authorize sets the credentials of a peer to "req", a request of this process to one of its peers.
+func (p *Pool) Authorize(req *http.Request) {
+	if p.secret != "" {
+		req.Header.Set(SecretHeader, p.secret)
+	}
+}
+
+// resolvePeers returns the IP addresses of the hosts of the "peers".
+// A host which fails to resolve is skipped until the next `Pool.Set`.
+func resolvePeers(peers []string) map[string]struct{} {
+	addrs := make(map[string]struct{}, len(peers))
+	for _, peer := range peers {
+		u, err := url.Parse(peer)
+		if err != nil {
+			continue
+		}
+
+		host := u.Hostname()
+		if ip := net.ParseIP(host); ip != nil {
+			addrs[canonicalIP(host)] = struct{}{}
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ips, _ := net.DefaultResolver.LookupHost(ctx, host)
+		cancel()
+		for _, ip := range ips {
+			addrs[canonicalIP(ip)] = struct{}{}
+		}
+	}
+
+	return addrs
+}
+
+// canonicalIP returns the string form of the "ip" address, e.g. of an IPv4-mapped IPv6 one.
+func canonicalIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+
+	return ip
+}
+
+// PeerStats holds the statistics of the requests of this process to one of its peers.
+type PeerStats struct {
+	Peer     string        `json:"peer"`
+	Self     bool          `json:"self"`
+	Requests int64         `json:"requests"`
+	Errors   int64         `json:"errors"`
+	Total    time.Duration `json:"total"`
+	Mean     time.Duration `json:"mean"`
+	Max      time.Duration `json:"max"`
+}
+
+// PeerStats returns the statistics of the requests to each of the current peers.
+func (p *Pool) PeerStats() []PeerStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make([]PeerStats, 0, len(p.peers))
+	for _, peer := range p.peers {
+		s := PeerStats{Peer: peer}
+		if counted, ok := p.stats[peer]; ok {
+			s = *counted
+		}
+		s.Self = peer == p.self
+		if s.Requests > 0 {
+			s.Mean = s.Total / time.Duration(s.Requests)
+		}
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+func (p *Pool) observe(peer string, took time.Duration, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s, ok := p.stats[peer]
+	if !ok {
+		s = &PeerStats{Peer: peer}
+		p.stats[peer] = s
+	}
+
+	s.Requests++
+	if failed {
+		s.Errors++
+	}
+	s.Total += took
+	if took > s.Max {
+		s.Max = took
+	}
+}
+
+// Close stops the discovery of the peers and, if the discovery is a `Registrar`, deregisters the process.
+func (p *Pool) Close() error {
+	p.cancel()
+	<-p.done
+
+	if r, ok := p.discovery.(Registrar); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return r.Deregister(ctx, p.self)
+	}
+
+	return nil
+}
+
+// countingTransport records the requests of the pool to its peers, see `Pool.PeerStats`.
+type countingTransport struct {
+	pool *Pool
+	next http.RoundTripper
+}
+
+func (t countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	t.pool.Authorize(req)
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	// the owner responds with 500 on a failed load and 404 on removed keys.
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+	t.pool.observe(req.URL.Scheme+"://"+req.URL.Host, time.Since(start), failed)
+
+	return resp, err
+}
+
+// normalizePeer returns the "peer" URL without a trailing slash, with the http scheme if missing.
+func normalizePeer(peer string) string {
+	peer = strings.TrimRight(strings.TrimSpace(peer), "/")
+	if peer != "" && !strings.Contains(peer, "://") {
+		peer = "http://" + peer
+	}
+
+	return peer
+}
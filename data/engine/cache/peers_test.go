@@ -0,0 +1,327 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPoolAuthorized(t *testing.T) {
+	// groupcache allows a single HTTP pool per process,
+	// these pools only authorize the requests.
+	secret := &Pool{secret: "s3cret"}
+	addresses := &Pool{addrs: resolvePeers([]string{"http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://[::1]:8080"})}
+
+	tests := []struct {
+		name       string
+		pool       *Pool
+		remoteAddr string
+		secret     string
+		expected   bool
+	}{
+		{"secret", secret, "192.168.1.1:4000", "s3cret", true},
+		{"wrong secret", secret, "10.0.0.2:4000", "secret", false},
+		{"no secret", secret, "10.0.0.2:4000", "", false},
+		{"peer address", addresses, "10.0.0.2:4000", "", true},
+		{"peer IPv6 address", addresses, "[::1]:4000", "", true},
+		{"other address", addresses, "10.0.0.3:4000", "", false},
+		{"invalid address", addresses, "10.0.0.2", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", DefaultBasePath+"products/%231", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.secret != "" {
+				req.Header.Set(SecretHeader, tt.secret)
+			}
+
+			if got := tt.pool.Authorized(req); got != tt.expected {
+				t.Fatalf("expected authorized to be %t but got %t", tt.expected, got)
+			}
+
+			if !tt.expected {
+				rec := httptest.NewRecorder()
+				tt.pool.ServeHTTP(rec, req)
+				if rec.Code != http.StatusForbidden {
+					t.Fatalf("expected status %d but got %d", http.StatusForbidden, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+// peerEnv is the env variable of the membership file of the processes started by `TestPoolPeers`.
+const peerEnv = "CACHE_TEST_PEERS_FILE"
+
+// peerReport is the state of a process started by `TestPoolPeers`.
+type peerReport struct {
+	Self  string        `json:"self"`
+	Peers []string      `json:"peers"`
+	Loads map[int64]int `json:"loads"`
+	Stats []PeerStats   `json:"stats"`
+}
+
+// TestPeerProcess is a peer of `TestPoolPeers`, groupcache allows a single pool per process.
+// It serves the pool, GET /get?id= to read an item through the cache,
+// GET /report for its `peerReport` and POST /close to leave the pool and exit.
+func TestPeerProcess(t *testing.T) {
+	membership := os.Getenv(peerEnv)
+	if membership == "" {
+		t.Skip("started by TestPoolPeers")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := NewPool("http://"+ln.Addr().String(), PoolOptions{
+		Discovery:       FilePeers{Path: membership},
+		RefreshInterval: 20 * time.Millisecond,
+		Secret:          "s3cret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := make(map[int64]string)
+	for id := int64(1); id <= peerItems; id++ {
+		items[id] = strconv.FormatInt(id, 10)
+	}
+	service := newItemService(new(cachedItem), items)
+	c := New(service, "peer-items", 0)
+
+	done := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.Handle(pool.BasePath(), pool)
+	mux.HandleFunc("/get", func(w http.ResponseWriter, r *http.Request) {
+		var b []byte
+		if err := c.GetByID(r.Context(), r.URL.Query().Get("id"), &b); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(b)
+	})
+	mux.HandleFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+		report := peerReport{Self: pool.Self(), Peers: pool.Peers(), Loads: make(map[int64]int), Stats: pool.PeerStats()}
+		for id := int64(1); id <= peerItems; id++ {
+			if n := service.loads(id); n > 0 {
+				report.Loads[id] = n
+			}
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+	mux.HandleFunc("/close", func(w http.ResponseWriter, r *http.Request) {
+		if err := pool.Close(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		close(done)
+	})
+
+	go http.Serve(ln, mux)
+	<-done
+}
+
+const peerItems = 50
+
+// TestPoolPeers starts the peers of a pool as separate processes which join through a membership file
+// and checks that each key is loaded once, by its owner, and the other peer asks the owner for it over HTTP.
+func TestPoolPeers(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts processes")
+	}
+	if os.Getenv(peerEnv) != "" {
+		t.Skip("a peer process")
+	}
+
+	membership := filepath.Join(t.TempDir(), "peers")
+	for i := 0; i < 2; i++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestPeerProcess$")
+		cmd.Env = append(os.Environ(), peerEnv+"="+membership)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer cmd.Process.Kill()
+	}
+
+	// the processes register themselves and discover each other.
+	var (
+		peers []string
+		err   error
+	)
+	eventually(t, "the peers to register", func() bool {
+		peers, err = FilePeers{Path: membership}.Peers(context.Background())
+		return err == nil && len(peers) == 2
+	})
+	eventually(t, "the peers to discover each other", func() bool {
+		for _, peer := range peers {
+			var report peerReport
+			if getJSON(peer+"/report", &report) != nil || len(report.Peers) != 2 {
+				return false
+			}
+		}
+		return true
+	})
+
+	for id := 1; id <= peerItems; id++ {
+		for _, peer := range peers {
+			var item cachedItem
+			if err = getJSON(peer+"/get?id="+strconv.Itoa(id), &item); err != nil {
+				t.Fatal(err)
+			}
+			if item.ID != int64(id) {
+				t.Fatalf("%s: expected item %d but got %#v", peer, id, item)
+			}
+		}
+	}
+
+	reports := make(map[string]peerReport)
+	for _, peer := range peers {
+		var report peerReport
+		if err = getJSON(peer+"/report", &report); err != nil {
+			t.Fatal(err)
+		}
+		reports[report.Self] = report
+	}
+
+	for id := int64(1); id <= peerItems; id++ {
+		loads := 0
+		for _, report := range reports {
+			loads += report.Loads[id]
+		}
+		if loads != 1 {
+			t.Fatalf("expected item %d to be loaded once but got %d loads", id, loads)
+		}
+	}
+
+	var remote int64
+	for self, report := range reports {
+		for _, stats := range report.Stats {
+			if stats.Self != (stats.Peer == self) {
+				t.Fatalf("%s: expected self to be %t for %s", self, !stats.Self, stats.Peer)
+			}
+
+			// every item owned by the other peer is requested once over HTTP.
+			owned := len(reports[stats.Peer].Loads)
+			if stats.Self {
+				owned = 0
+			}
+			if stats.Requests != int64(owned) || stats.Errors != 0 {
+				t.Fatalf("%s: expected %d requests to %s without errors but got %d requests and %d errors",
+					self, owned, stats.Peer, stats.Requests, stats.Errors)
+			}
+		}
+
+		for _, stats := range report.Stats {
+			remote += stats.Requests
+		}
+	}
+	if remote == 0 {
+		t.Fatalf("expected the peers to request the items they do not own over HTTP")
+	}
+
+	// the processes deregister themselves on close.
+	for _, peer := range peers {
+		resp, err := http.Post(peer+"/close", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: close: %s", peer, resp.Status)
+		}
+	}
+	if peers, err = (FilePeers{Path: membership}).Peers(context.Background()); err != nil || len(peers) != 0 {
+		t.Fatalf("expected no peers after close but got %v (%v)", peers, err)
+	}
+}
+
+func getJSON(url string, dest interface{}) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
+
+// eventually waits up to 5 seconds for "ok" to report true.
+func eventually(t *testing.T, what string, ok func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if ok() {
+			return
+		}
+	}
+
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestDiscovery(t *testing.T) {
+	ctx := context.Background()
+
+	peers, err := StaticPeers{"http://a:8080", "http://b:8080"}.Peers(ctx)
+	if err != nil || !reflect.DeepEqual(peers, []string{"http://a:8080", "http://b:8080"}) {
+		t.Fatalf("static: expected the list but got %v (%v)", peers, err)
+	}
+
+	peers, err = DNSPeers{Host: "127.0.0.1", Port: "8080"}.Peers(ctx)
+	if err != nil || !reflect.DeepEqual(peers, []string{"http://127.0.0.1:8080"}) {
+		t.Fatalf("dns: expected [http://127.0.0.1:8080] but got %v (%v)", peers, err)
+	}
+
+	membership := FilePeers{Path: filepath.Join(t.TempDir(), "peers")}
+	if peers, err = membership.Peers(ctx); err != nil || len(peers) != 0 {
+		t.Fatalf("file: expected no peers before the file exists but got %v (%v)", peers, err)
+	}
+
+	if err = os.WriteFile(membership.Path, []byte("# peers\n\nhttp://a:8080/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, self := range []string{"http://b:8080", "http://a:8080", "http://b:8080"} {
+		if err = membership.Register(ctx, self); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if peers, err = membership.Peers(ctx); err != nil || !reflect.DeepEqual(peers, []string{"http://a:8080/", "http://b:8080"}) {
+		t.Fatalf("file: expected each peer registered once but got %v (%v)", peers, err)
+	}
+
+	if err = membership.Deregister(ctx, "http://a:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if peers, err = membership.Peers(ctx); err != nil || !reflect.DeepEqual(peers, []string{"http://b:8080"}) {
+		t.Fatalf("file: expected [http://b:8080] after deregister but got %v (%v)", peers, err)
+	}
+
+	if err = membership.Deregister(ctx, "http://b:8080"); err != nil {
+		t.Fatal(err)
+	}
+	if peers, err = membership.Peers(ctx); err != nil || len(peers) != 0 {
+		t.Fatalf("file: expected no peers after deregister but got %v (%v)", peers, err)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(membership.Path))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("file: expected no temporary files left but got %v (%v)", entries, err)
+	}
+}
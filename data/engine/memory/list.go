@@ -0,0 +1,576 @@
+package memory
+
+import (
+	"context"
+	dbsql "database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"morshed/data/engine/sql"
+)
+
+// ErrDuplicate is returned by `Table.Insert` when the primary key is already in use.
+var ErrDuplicate = errors.New("memory: duplicate primary key")
+
+// List binds one or more records to the "dest" based on the "opts",
+// see `sql.Repository.List`. Filters are evaluated against the stored records,
+// string comparisons are case-insensitive like the default MySQL collation.
+func (t *Table) List(ctx context.Context, dest interface{}, opts sql.ListOptions) error {
+	opts, rows, err := t.list(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	return t.bindAll(dest, rows)
+}
+
+// ListPage same as `List` but it returns a `sql.Page` of the "dest"
+// and the cursor of the next set of records, see `sql.Repository.ListPage`.
+func (t *Table) ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error) {
+	opts, rows, err := t.list(ctx, opts)
+	if err != nil {
+		return sql.Page{}, err
+	}
+
+	if err = t.bindAll(dest, rows); err != nil {
+		return sql.Page{}, err
+	}
+
+	return sql.Page{Items: dest, NextCursor: sql.NextCursor(dest, opts)}, nil
+}
+
+func (t *Table) list(ctx context.Context, opts sql.ListOptions) (sql.ListOptions, []reflect.Value, error) {
+	if opts.Table != "" && opts.Table != t.rec.TableName() {
+		return opts, nil, sql.ErrUnprocessable
+	}
+	opts.Table = t.rec.TableName()
+	opts.PrimaryKey = t.rec.PrimaryKey()
+
+	if opts.OrderByColumn == "" {
+		if b, ok := t.rec.(sql.Sorted); ok {
+			opts.OrderByColumn = b.SortBy()
+		}
+	}
+
+	var orderColumns []string
+	for _, column := range strings.Split(opts.OrderByColumn, ",") {
+		if column = strings.TrimSpace(column); column != "" && column != opts.PrimaryKey {
+			orderColumns = append(orderColumns, column)
+		}
+	}
+	if err := sql.ValidateColumns(t.rec, orderColumns...); err != nil {
+		return opts, nil, err
+	}
+	orderColumns = append(orderColumns, opts.PrimaryKey)
+	desc := sql.ParseOrder(opts.Order) == "DESC"
+
+	filter := sql.And(opts.Filter)
+	if opts.WhereColumn != "" && opts.WhereValue != nil {
+		filter.Filters = append(filter.Filters, sql.Eq(opts.WhereColumn, opts.WhereValue))
+	}
+	match, err := t.compile(filter)
+	if err != nil {
+		return opts, nil, err
+	}
+
+	var after func(row reflect.Value) bool
+	if opts.Cursor != "" {
+		if len(orderColumns) > 2 {
+			return opts, nil, sql.ErrUnprocessable
+		}
+
+		c, err := sql.DecodeCursor(opts.Cursor)
+		if err != nil {
+			return opts, nil, sql.ErrUnprocessable
+		}
+
+		after = t.after(orderColumns[0], c, desc)
+	}
+
+	if opts.IncludeDeleted {
+		ctx = sql.WithDeleted(ctx)
+	}
+
+	t.mu.RLock()
+	var rows []reflect.Value
+	for _, row := range t.rows {
+		if match(row) && (after == nil || after(row)) && !t.hidden(ctx, row) {
+			rows = append(rows, row)
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, column := range orderColumns {
+			a, _ := t.field(rows[i], column)
+			b, _ := t.field(rows[j], column)
+			if n := compareFields(a, b); n != 0 {
+				return (n < 0) != desc
+			}
+		}
+		return false
+	})
+
+	if opts.Cursor == "" && opts.Offset > 0 {
+		if opts.Offset >= uint64(len(rows)) {
+			rows = nil
+		} else {
+			rows = rows[opts.Offset:]
+		}
+	}
+
+	if opts.Limit > 0 && opts.Limit < uint64(len(rows)) {
+		rows = rows[:opts.Limit]
+	}
+
+	return opts, rows, nil
+}
+
+// after returns a predicate which reports whether a row
+// comes after the cursor on the "column", primary key order.
+// A nil cursor value is a NULL one, NULLs come first like on `compareFields`.
+func (t *Table) after(column string, c sql.Cursor, desc bool) func(row reflect.Value) bool {
+	pk := t.rec.PrimaryKey()
+	return func(row reflect.Value) bool {
+		n := 0
+		if column != pk {
+			f, _ := t.field(row, column)
+			switch null := isNil(f); {
+			case null && c.Value == nil:
+			case null:
+				n = -1
+			case c.Value == nil:
+				n = 1
+			default:
+				n, _ = compare(f, c.Value)
+			}
+		}
+		if n == 0 {
+			id := t.id(row)
+			switch {
+			case id < c.ID:
+				n = -1
+			case id > c.ID:
+				n = 1
+			}
+		}
+
+		if desc {
+			return n < 0
+		}
+		return n > 0
+	}
+}
+
+// compile converts a `sql.Filter` to a row predicate.
+// Filter columns are validated against the record's columns.
+func (t *Table) compile(f sql.Filter) (func(row reflect.Value) bool, error) {
+	switch f := f.(type) {
+	case nil:
+		return func(reflect.Value) bool { return true }, nil
+	case sql.Group:
+		preds := make([]func(reflect.Value) bool, 0, len(f.Filters))
+		for _, child := range f.Filters {
+			if child == nil {
+				continue
+			}
+
+			pred, err := t.compile(child)
+			if err != nil {
+				return nil, err
+			}
+			preds = append(preds, pred)
+		}
+
+		if len(preds) == 0 {
+			return func(reflect.Value) bool { return true }, nil
+		}
+
+		return func(row reflect.Value) bool {
+			for _, pred := range preds {
+				if pred(row) == f.Or {
+					return f.Or
+				}
+			}
+			return !f.Or
+		}, nil
+	case sql.Condition:
+		return t.compileCondition(f)
+	default:
+		return nil, sql.ErrUnprocessable
+	}
+}
+
+func (t *Table) compileCondition(c sql.Condition) (func(row reflect.Value) bool, error) {
+	index, ok := t.fields[c.Column]
+	if !ok {
+		return nil, sql.ErrUnprocessable
+	}
+	field := func(row reflect.Value) reflect.Value { return row.FieldByIndex(index) }
+
+	switch c.Op {
+	case sql.OpIsNull:
+		isNull, ok := c.Value.(bool)
+		if !ok {
+			isNull = true
+		}
+
+		return func(row reflect.Value) bool {
+			return isNil(field(row)) == isNull
+		}, nil
+	case sql.OpIn, sql.OpNotIn:
+		values := reflect.ValueOf(c.Value)
+		if values.Kind() != reflect.Slice {
+			values = reflect.ValueOf([]interface{}{c.Value})
+		}
+
+		in := c.Op == sql.OpIn
+		return func(row reflect.Value) bool {
+			f := field(row)
+			if isNil(f) {
+				return false
+			}
+
+			for i := 0; i < values.Len(); i++ {
+				if n, ok := compare(f, values.Index(i).Interface()); ok && n == 0 {
+					return in
+				}
+			}
+			return !in
+		}, nil
+	case sql.OpLike:
+		pattern, ok := c.Value.(string)
+		if !ok {
+			return nil, sql.ErrUnprocessable
+		}
+
+		re, err := likeRegexp(pattern)
+		if err != nil {
+			return nil, sql.ErrUnprocessable
+		}
+
+		return func(row reflect.Value) bool {
+			f := field(row)
+			if isNil(f) {
+				return false
+			}
+			v := reflect.Indirect(f).Interface()
+			if valuer, ok := v.(driver.Valuer); ok {
+				v, _ = valuer.Value()
+			}
+			return re.MatchString(fmt.Sprint(v))
+		}, nil
+	default:
+		var test func(n int) bool
+		switch c.Op {
+		case sql.OpEq, "":
+			test = func(n int) bool { return n == 0 }
+		case sql.OpNe:
+			test = func(n int) bool { return n != 0 }
+		case sql.OpGt:
+			test = func(n int) bool { return n > 0 }
+		case sql.OpGte:
+			test = func(n int) bool { return n >= 0 }
+		case sql.OpLt:
+			test = func(n int) bool { return n < 0 }
+		case sql.OpLte:
+			test = func(n int) bool { return n <= 0 }
+		default:
+			return nil, sql.ErrUnprocessable
+		}
+
+		return func(row reflect.Value) bool {
+			n, ok := compare(field(row), c.Value)
+			return ok && test(n)
+		}, nil
+	}
+}
+
+// likeRegexp converts a LIKE pattern to a case-insensitive regular expression.
+func likeRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(".*")
+		case r == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func isNil(f reflect.Value) bool {
+	if n, ok := f.Interface().(sql.Nullable); ok {
+		return n.IsNull()
+	}
+
+	switch f.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		return f.IsNil()
+	default:
+		return false
+	}
+}
+
+// normalize returns the comparable form of a field:
+// float64 for numbers and booleans, string for text, time.Time for times
+// and nil for NULL or unsupported values.
+func normalize(f reflect.Value) interface{} {
+	if isNil(f) {
+		return nil
+	}
+	f = reflect.Indirect(f)
+
+	if valuer, ok := f.Interface().(driver.Valuer); ok {
+		// e.g. sql.NullString.
+		v, err := valuer.Value()
+		if err != nil || v == nil {
+			return nil
+		}
+		f = reflect.ValueOf(v)
+	}
+
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(f.Uint())
+	case reflect.Float32, reflect.Float64:
+		return f.Float()
+	case reflect.Bool:
+		if f.Bool() {
+			return float64(1)
+		}
+		return float64(0)
+	case reflect.String:
+		return f.String()
+	case reflect.Slice:
+		if b, ok := f.Interface().([]byte); ok {
+			return string(b)
+		}
+	case reflect.Struct:
+		if tm, ok := f.Interface().(time.Time); ok {
+			return tm
+		}
+	}
+
+	return nil
+}
+
+// convert converts "v" to the comparable form of "like", see `normalize`.
+func convert(v interface{}, like interface{}) (interface{}, bool) {
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+
+	switch like.(type) {
+	case float64:
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+				v = b
+			} else {
+				f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+				return f, err == nil
+			}
+		}
+	case string:
+		switch value := v.(type) {
+		case string:
+			return value, true
+		case []byte:
+			return string(value), true
+		default:
+			return fmt.Sprint(v), true
+		}
+	case time.Time:
+		switch value := v.(type) {
+		case time.Time:
+			return value, true
+		case *time.Time:
+			if value == nil {
+				return nil, false
+			}
+			return *value, true
+		case string:
+			for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+				if tm, err := time.Parse(layout, value); err == nil {
+					return tm, true
+				}
+			}
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+
+	c := normalize(reflect.ValueOf(v))
+	_, ok := c.(float64)
+	return c, ok
+}
+
+// compare compares the "f" field with the "v" value.
+// It reports false if they cannot be compared, e.g. NULL fields.
+func compare(f reflect.Value, v interface{}) (int, bool) {
+	a := normalize(f)
+	if a == nil || v == nil {
+		return 0, false
+	}
+
+	b, ok := convert(v, a)
+	if !ok {
+		return 0, false
+	}
+
+	return compareNormalized(a, b), true
+}
+
+// compareFields is used for sorting, NULLs come first like on MySQL.
+func compareFields(f1, f2 reflect.Value) int {
+	a, b := normalize(f1), normalize(f2)
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	return compareNormalized(a, b)
+}
+
+func compareNormalized(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		b, _ := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	case time.Time:
+		b, _ := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// assign sets the "v" value to the "f" field, converting between numbers,
+// strings and times where possible. A nil "v" sets the zero value.
+func assign(f reflect.Value, v interface{}) error {
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+
+	if v == nil {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Type().AssignableTo(f.Type()) {
+		f.Set(rv)
+		return nil
+	}
+
+	target := f
+	if f.Kind() == reflect.Ptr {
+		target = reflect.New(f.Type().Elem()).Elem()
+	}
+
+	if scanner, ok := target.Addr().Interface().(dbsql.Scanner); ok && target.Kind() == reflect.Struct {
+		// e.g. sql.NullString.
+		if err := scanner.Scan(v); err != nil {
+			return sql.ErrUnprocessable
+		}
+
+		if f.Kind() == reflect.Ptr {
+			f.Set(target.Addr())
+		}
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := convert(v, float64(0))
+		if !ok || n.(float64) != float64(int64(n.(float64))) {
+			return sql.ErrUnprocessable
+		}
+		target.SetInt(int64(n.(float64)))
+	case reflect.Float32, reflect.Float64:
+		n, ok := convert(v, float64(0))
+		if !ok {
+			return sql.ErrUnprocessable
+		}
+		target.SetFloat(n.(float64))
+	case reflect.Bool:
+		n, ok := convert(v, float64(0))
+		if !ok {
+			return sql.ErrUnprocessable
+		}
+		target.SetBool(n.(float64) != 0)
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return sql.ErrUnprocessable
+		}
+		target.SetString(s)
+	default:
+		if rv.Type().ConvertibleTo(target.Type()) && rv.Kind() == target.Kind() {
+			target.Set(rv.Convert(target.Type()))
+			break
+		}
+
+		if k := target.Kind(); k == reflect.Slice || k == reflect.Map {
+			// JSON columns, e.g. a []interface{} of a request body to a `sql.StringList`.
+			b, err := json.Marshal(v)
+			if err != nil || json.Unmarshal(b, target.Addr().Interface()) != nil {
+				return sql.ErrUnprocessable
+			}
+			break
+		}
+
+		tm, ok := convert(v, time.Time{})
+		if !ok || target.Type() != reflect.TypeOf(time.Time{}) {
+			return sql.ErrUnprocessable
+		}
+		target.Set(reflect.ValueOf(tm))
+	}
+
+	if f.Kind() == reflect.Ptr {
+		f.Set(target.Addr())
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"morshed/data/engine/sql"
+)
+
+type rankedItem struct {
+	ID   int64  `db:"id"`
+	Rank *int64 `db:"rank"`
+}
+
+func (rankedItem) TableName() string  { return "ranked_items" }
+func (rankedItem) PrimaryKey() string { return "id" }
+
+func rank(n int64) *int64 {
+	return &n
+}
+
+// listAll pages through the table "limit" records at a time and returns their ids.
+func listAll(t *testing.T, table *Table, opts sql.ListOptions) []int64 {
+	t.Helper()
+
+	var ids []int64
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("the pagination does not end")
+		}
+
+		var list []rankedItem
+		page, err := table.ListPage(context.Background(), &list, opts)
+		if err == sql.ErrNoRows {
+			return ids
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, item := range list {
+			ids = append(ids, item.ID)
+		}
+
+		if page.NextCursor == "" {
+			return ids
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func TestListCursorNulls(t *testing.T) {
+	table := NewTable(new(rankedItem))
+	for _, r := range []*int64{nil, rank(2), nil, rank(1), rank(2), rank(3), nil} {
+		if _, err := table.Insert(context.Background(), rankedItem{Rank: r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		order    string
+		expected []int64
+	}{
+		{"ascending, the NULLs come first", "asc", []int64{1, 3, 7, 4, 2, 5, 6}},
+		{"descending, the NULLs come last", "desc", []int64{6, 5, 2, 4, 7, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		for _, limit := range []uint64{1, 2, 3} {
+			opts := sql.ListOptions{OrderByColumn: "rank", Order: tt.order, Limit: limit}
+			if got := listAll(t, table, opts); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("%s by %d: expected %v but got %v", tt.name, limit, tt.expected, got)
+			}
+		}
+	}
+}
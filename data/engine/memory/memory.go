@@ -0,0 +1,503 @@
+// Package memory is an in-memory storage engine.
+// It keeps the records of each `sql.Record` type on a `Table`
+// and mirrors the semantics of the `sql.Repository` methods,
+// so the application can run without a database server.
+package memory
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"morshed/data/engine/sql"
+)
+
+// DB is an in-memory database, a set of tables by their name.
+type DB struct {
+	mu     sync.Mutex
+	tables map[string]*Table
+}
+
+// New returns a new empty in-memory database.
+func New() *DB {
+	return &DB{tables: make(map[string]*Table)}
+}
+
+// Table returns the table of the "rec" record, it is created on first use.
+func (db *DB) Table(rec sql.Record) *Table {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, ok := db.tables[rec.TableName()]
+	if !ok {
+		t = NewTable(rec)
+		db.tables[rec.TableName()] = t
+	}
+
+	return t
+}
+
+// Table holds the records of a single `sql.Record` type by their primary key.
+// Records are copied in and out, callers never share memory with the table.
+type Table struct {
+	mu     sync.RWMutex
+	rec    sql.Record
+	typ    reflect.Type            // the struct type of the record.
+	fields map[string][]int        // column name to field index.
+	schema map[string]reflect.Kind // the `sql.UpdateSchemaOf` columns, see `PartialUpdate`.
+	rows   map[int64]reflect.Value
+	nextID int64
+}
+
+// NewTable returns a new empty table of the "rec" records
+// which does not belong to a `DB`, e.g. to evaluate `List` options
+// over records loaded from another storage engine.
+func NewTable(rec sql.Record) *Table {
+	typ := reflect.TypeOf(rec)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	return &Table{
+		rec:    rec,
+		typ:    typ,
+		fields: sql.FieldsOf(typ),
+		schema: sql.UpdateSchemaOf(rec),
+		rows:   make(map[int64]reflect.Value),
+	}
+}
+
+// RecordInfo returns the record info of the table.
+func (t *Table) RecordInfo() sql.Record {
+	return t.rec
+}
+
+// New returns a pointer to a new zero record of the table's type.
+func (t *Table) New() interface{} {
+	return reflect.New(t.typ).Interface()
+}
+
+func (t *Table) field(row reflect.Value, column string) (reflect.Value, bool) {
+	index, ok := t.fields[column]
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	return row.FieldByIndex(index), true
+}
+
+func (t *Table) id(row reflect.Value) int64 {
+	f, ok := t.field(row, t.rec.PrimaryKey())
+	if !ok {
+		return 0
+	}
+
+	return f.Int()
+}
+
+// structValue returns the struct value of "v" which may be a struct or a pointer to it.
+func (t *Table) structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, sql.ErrUnprocessable
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() != t.typ {
+		return reflect.Value{}, sql.ErrUnprocessable
+	}
+
+	return rv, nil
+}
+
+// copyRow returns a new addressable deep copy of the "row" struct,
+// its slice, map, pointer and interface fields are copied as well.
+func (t *Table) copyRow(row reflect.Value) reflect.Value {
+	c := reflect.New(t.typ).Elem()
+	c.Set(row)
+	deepCopy(c)
+	return c
+}
+
+// deepCopy replaces the memory which "v", a settable value, shares with its source with copies.
+// Unexported struct fields (e.g. of time.Time) are left shared.
+func deepCopy(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(v.Elem())
+		deepCopy(c.Elem())
+		v.Set(c)
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.New(v.Elem().Type()).Elem()
+		c.Set(v.Elem())
+		deepCopy(c)
+		v.Set(c)
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		for i := 0; i < c.Len(); i++ {
+			deepCopy(c.Index(i))
+		}
+		v.Set(c)
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(iter.Value())
+			deepCopy(value)
+			c.SetMapIndex(iter.Key(), value)
+		}
+		v.Set(c)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			deepCopy(v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.CanSet() {
+				deepCopy(f)
+			}
+		}
+	}
+}
+
+// bind copies the "row" to "dest", a pointer to the table's struct.
+func (t *Table) bind(dest interface{}, row reflect.Value) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Type() != t.typ {
+		return sql.ErrUnprocessable
+	}
+
+	rv.Elem().Set(t.copyRow(row))
+	return nil
+}
+
+// bindAll appends the "rows" to "dest", a pointer to a slice of the table's struct or pointers to it.
+// Like the models' `Scannable` implementations, it returns `sql.ErrNoRows` when "rows" is empty.
+func (t *Table) bindAll(dest interface{}, rows []reflect.Value) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return sql.ErrUnprocessable
+	}
+
+	list := rv.Elem()
+	elemType := list.Type().Elem()
+	for _, row := range rows {
+		switch {
+		case elemType == t.typ:
+			list = reflect.Append(list, t.copyRow(row))
+		case elemType.Kind() == reflect.Ptr && elemType.Elem() == t.typ:
+			list = reflect.Append(list, t.copyRow(row).Addr())
+		default:
+			return sql.ErrUnprocessable
+		}
+	}
+
+	if len(rows) == 0 {
+		return sql.ErrNoRows
+	}
+
+	rv.Elem().Set(list)
+	return nil
+}
+
+// sorted returns the rows ordered by their primary key.
+func (t *Table) sorted() []reflect.Value {
+	rows := make([]reflect.Value, 0, len(t.rows))
+	for _, row := range t.rows {
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return t.id(rows[i]) < t.id(rows[j])
+	})
+
+	return rows
+}
+
+// hidden reports whether the "row" is soft deleted and should be hidden on "ctx",
+// see `sql.SoftDeletable`.
+func (t *Table) hidden(ctx context.Context, row reflect.Value) bool {
+	return !sql.IncludesDeleted(ctx) && sql.IsDeleted(row.Addr().Interface())
+}
+
+// Count returns the total records count in the table.
+func (t *Table) Count(ctx context.Context) (int64, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if sql.SoftDeleteColumnOf(t.rec) == "" {
+		return int64(len(t.rows)), nil
+	}
+
+	var total int64
+	for _, row := range t.rows {
+		if !t.hidden(ctx, row) {
+			total++
+		}
+	}
+
+	return total, nil
+}
+
+// GetByID binds a single record to the "dest".
+func (t *Table) GetByID(ctx context.Context, dest interface{}, id int64) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	row, ok := t.rows[id]
+	if !ok || t.hidden(ctx, row) {
+		return sql.ErrNoRows
+	}
+
+	return t.bind(dest, row)
+}
+
+// GetByAttrs binds the first record that matches all the "attrs" column-value pairs to the "dest".
+func (t *Table) GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	var filters []sql.Filter
+	for column, value := range attrs {
+		filters = append(filters, sql.Eq(column, value))
+	}
+
+	match, err := t.compile(sql.And(filters...))
+	if err != nil {
+		return err
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, row := range t.sorted() {
+		if match(row) && !t.hidden(ctx, row) {
+			return t.bind(dest, row)
+		}
+	}
+
+	return sql.ErrNoRows
+}
+
+// GetAll binds all the records of the table to the "dest".
+func (t *Table) GetAll(ctx context.Context, dest interface{}) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var rows []reflect.Value
+	for _, row := range t.sorted() {
+		if !t.hidden(ctx, row) {
+			rows = append(rows, row)
+		}
+	}
+
+	return t.bindAll(dest, rows)
+}
+
+// DeleteByID removes a single record from the table,
+// `sql.SoftDeletable` records are marked as deleted instead, see `Purge`.
+func (t *Table) DeleteByID(ctx context.Context, id int64) (int, error) {
+	column := sql.SoftDeleteColumnOf(t.rec)
+	if column == "" {
+		return t.Purge(ctx, id)
+	}
+
+	return t.setDeleted(id, column, true)
+}
+
+// Restore brings back the soft deleted "id" record, see `sql.Repository.Restore`.
+func (t *Table) Restore(ctx context.Context, id int64) (int, error) {
+	column := sql.SoftDeleteColumnOf(t.rec)
+	if column == "" {
+		return 0, sql.ErrUnprocessable
+	}
+
+	return t.setDeleted(id, column, false)
+}
+
+func (t *Table) setDeleted(id int64, column string, deleted bool) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old, ok := t.rows[id]
+	if !ok || sql.IsDeleted(old.Addr().Interface()) == deleted {
+		return 0, nil
+	}
+
+	row := t.copyRow(old)
+	if deleted {
+		sql.Touch(row.Addr().Interface(), column, time.Now(), true)
+	} else if f, ok := t.field(row, column); ok {
+		f.Set(reflect.Zero(f.Type()))
+	}
+
+	t.rows[id] = row
+	return 1, nil
+}
+
+// Purge removes a single record from the table, even if it is `sql.SoftDeletable`.
+func (t *Table) Purge(ctx context.Context, id int64) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.rows[id]; !ok {
+		return 0, nil
+	}
+
+	delete(t.rows, id)
+	return 1, nil
+}
+
+// Insert stores a copy of the "rec" and returns its primary key.
+// A zero primary key is generated (auto increment)
+// and nil created_at and updated_at columns are set to the current time.
+func (t *Table) Insert(ctx context.Context, rec interface{}) (int64, error) {
+	rv, err := t.structValue(rec)
+	if err != nil {
+		return 0, err
+	}
+	row := t.copyRow(rv)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := t.id(row)
+	if id <= 0 {
+		t.nextID++
+		id = t.nextID
+		f, _ := t.field(row, t.rec.PrimaryKey())
+		f.SetInt(id)
+	} else if _, exists := t.rows[id]; exists {
+		return 0, ErrDuplicate
+	} else if id > t.nextID {
+		t.nextID = id
+	}
+
+	now := time.Now()
+	sql.Touch(row.Addr().Interface(), "created_at", now, false)
+	sql.Touch(row.Addr().Interface(), "updated_at", now, false)
+
+	t.rows[id] = row
+	return id, nil
+}
+
+// Update replaces the stored record with the same primary key as "rec".
+// It returns the affected number (0 when the record does not exist or it is soft deleted, otherwise 1).
+// A `sql.Versioned` record with a version different than the stored one
+// results to `sql.ErrConflict`.
+func (t *Table) Update(ctx context.Context, rec interface{}) (int, error) {
+	rv, err := t.structValue(rec)
+	if err != nil {
+		return 0, err
+	}
+	row := t.copyRow(rv)
+	id := t.id(row)
+	expected, _ := sql.VersionOf(row.Addr().Interface())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old, ok := t.rows[id]
+	if !ok || t.hidden(ctx, old) {
+		return 0, nil
+	}
+
+	if err = sql.CheckVersion(old.Addr().Interface(), expected); err != nil {
+		return 0, err
+	}
+
+	// created_at is not part of an update.
+	if created, ok := t.field(old, "created_at"); ok {
+		f, _ := t.field(row, "created_at")
+		f.Set(created)
+	}
+	now := time.Now()
+	sql.Touch(row.Addr().Interface(), "updated_at", now, true)
+	sql.NextVersion(row.Addr().Interface(), old.Addr().Interface(), now)
+
+	t.rows[id] = row
+	return 1, nil
+}
+
+// PartialUpdate accepts a key-value map to update the record based on the given "id".
+// Values are converted to the column's type, columns out of the `sql.UpdateSchemaOf` the record
+// or inconvertible values result to `sql.ErrUnprocessable`, like on `sql.Repository.PartialUpdate`.
+// A nil value sets a nullable column to NULL, see `sql.IsNullable`.
+// The version column of a `sql.Versioned` record is checked like on `Update`.
+func (t *Table) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error) {
+	if len(attrs) == 0 {
+		return 0, nil
+	}
+
+	expected, attrs, err := sql.SplitVersion(t.rec, attrs)
+	if err != nil {
+		return 0, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	old, ok := t.rows[id]
+	if !ok || t.hidden(ctx, old) {
+		return 0, nil
+	}
+
+	if err = sql.CheckVersion(old.Addr().Interface(), expected); err != nil {
+		return 0, err
+	}
+
+	row := t.copyRow(old)
+	for column, value := range attrs {
+		f, ok := t.field(row, column)
+		if _, updatable := t.schema[column]; !ok || !updatable || (value == nil && !sql.IsNullable(t.rec, column)) {
+			return 0, sql.ErrUnprocessable
+		}
+
+		if err := assign(f, value); err != nil {
+			return 0, err
+		}
+		deepCopy(f)
+	}
+	now := time.Now()
+	sql.Touch(row.Addr().Interface(), "updated_at", now, true)
+	sql.NextVersion(row.Addr().Interface(), old.Addr().Interface(), now)
+
+	t.rows[id] = row
+	return 1, nil
+}
+
+// Set sets the "column" of "dest", a pointer to a record of the table's type,
+// the "value" is converted to the column's type like on `PartialUpdate`.
+func (t *Table) Set(dest interface{}, column string, value interface{}) error {
+	row, err := t.structValue(dest)
+	if err != nil {
+		return err
+	}
+
+	f, ok := t.field(row, column)
+	if !ok {
+		return sql.ErrUnprocessable
+	}
+
+	return assign(f, value)
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"morshed/data/engine/sql"
+)
+
+type taggedItem struct {
+	ID   int64    `db:"id"`
+	Tags []string `db:"tags"`
+	Data []byte   `db:"data"`
+	Rank *int64   `db:"rank"`
+}
+
+func (taggedItem) TableName() string  { return "tagged_items" }
+func (taggedItem) PrimaryKey() string { return "id" }
+
+func TestTableCopiesRows(t *testing.T) {
+	table := NewTable(new(taggedItem))
+	ctx := context.Background()
+
+	item := taggedItem{Tags: []string{"a", "b"}, Data: []byte("data"), Rank: rank(1)}
+	id, err := table.Insert(ctx, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := taggedItem{ID: id, Tags: []string{"a", "b"}, Data: []byte("data"), Rank: rank(1)}
+
+	// modify the inserted record.
+	item.Tags[0], item.Data[0], *item.Rank = "x", 'x', 9
+
+	var got taggedItem
+	if err = table.GetByID(ctx, &got, id); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected the stored record to be %#v but got %#v", expected, got)
+	}
+
+	// modify the read records.
+	got.Tags[0], got.Data[0], *got.Rank = "y", 'y', 8
+	var list []*taggedItem
+	if err = table.GetAll(ctx, &list); err != nil {
+		t.Fatal(err)
+	}
+	list[0].Tags[1] = "z"
+
+	got = taggedItem{}
+	if err = table.GetByID(ctx, &got, id); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected the stored record to be %#v but got %#v", expected, got)
+	}
+}
+
+type stampedItem struct {
+	ID        int64        `db:"id"`
+	Name      string       `db:"name"`
+	CreatedAt time.Time    `db:"created_at"`
+	UpdatedAt sql.NullTime `db:"updated_at"`
+}
+
+func (stampedItem) TableName() string  { return "stamped_items" }
+func (stampedItem) PrimaryKey() string { return "id" }
+
+func TestTablePartialUpdate(t *testing.T) {
+	table := NewTable(new(stampedItem))
+	ctx := context.Background()
+
+	id, err := table.Insert(ctx, stampedItem{Name: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var inserted stampedItem
+	if err = table.GetByID(ctx, &inserted, id); err != nil {
+		t.Fatal(err)
+	}
+	if inserted.CreatedAt.IsZero() || !inserted.UpdatedAt.Valid {
+		t.Fatalf("expected the timestamps to be set on insert but got %#v", inserted)
+	}
+
+	tests := []struct {
+		attrs map[string]interface{}
+		err   error
+	}{
+		{map[string]interface{}{"name": "b"}, nil},
+		{map[string]interface{}{"id": 2}, sql.ErrUnprocessable},
+		{map[string]interface{}{"created_at": "2020-01-01T00:00:00Z"}, sql.ErrUnprocessable},
+		{map[string]interface{}{"updated_at": "2020-01-01T00:00:00Z"}, sql.ErrUnprocessable},
+		{map[string]interface{}{"name": "c", "unknown": 1}, sql.ErrUnprocessable},
+	}
+
+	for i, tt := range tests {
+		if _, err = table.PartialUpdate(ctx, id, tt.attrs); err != tt.err {
+			t.Fatalf("[%d] expected error %v but got %v", i, tt.err, err)
+		}
+	}
+
+	var got stampedItem
+	if err = table.GetByID(ctx, &got, id); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "b" || !got.CreatedAt.Equal(inserted.CreatedAt) || got.UpdatedAt.Time.Before(inserted.UpdatedAt.Time) {
+		t.Fatalf("expected only the name to be patched and updated_at to be touched but got %#v", got)
+	}
+}
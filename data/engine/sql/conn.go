@@ -0,0 +1,153 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// ConnOptions holds the connection pool, timeout and retry settings of a database,
+// see `ConnectMySQLWith` and `ConnectPostgresWith`. Zero fields keep the database/sql defaults, i.e. no limits and no retries.
+type ConnOptions struct {
+	// MaxOpenConns is the maximum number of open connections, see `sql.DB.SetMaxOpenConns`.
+	MaxOpenConns int
+	// MaxIdleConns is the maximum number of idle connections, see `sql.DB.SetMaxIdleConns`.
+	MaxIdleConns int
+	// ConnMaxLifetime is the maximum time a connection may be reused.
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime is the maximum time a connection may stay idle.
+	ConnMaxIdleTime time.Duration
+
+	// QueryTimeout is the timeout of the queries whose context has no deadline.
+	QueryTimeout time.Duration
+
+	// ConnectRetries is the number of extra attempts to reach the server on connect,
+	// e.g. while it is still starting.
+	ConnectRetries int
+	// ConnectBackoff is the wait before the first connect retry, it is doubled on each retry
+	// up to `MaxConnectBackoff`. Defaults to 1 second.
+	ConnectBackoff time.Duration
+
+	// ReadRetries is the number of extra attempts of a `Select` or `Get` query
+	// which fails with a transient error, e.g. a dropped connection or a deadlock.
+	// Writes are never retried.
+	ReadRetries int
+}
+
+// MaxConnectBackoff is the maximum wait between two connect attempts.
+var MaxConnectBackoff = 30 * time.Second
+
+// apply sets the pool settings of "opts" to the "conn".
+func (opts ConnOptions) apply(conn *sql.DB) {
+	if opts.MaxOpenConns > 0 {
+		conn.SetMaxOpenConns(opts.MaxOpenConns)
+	}
+	if opts.MaxIdleConns > 0 {
+		conn.SetMaxIdleConns(opts.MaxIdleConns)
+	}
+	if opts.ConnMaxLifetime > 0 {
+		conn.SetConnMaxLifetime(opts.ConnMaxLifetime)
+	}
+	if opts.ConnMaxIdleTime > 0 {
+		conn.SetConnMaxIdleTime(opts.ConnMaxIdleTime)
+	}
+}
+
+// ping checks the connection of "conn", it is retried `ConnectRetries` times with backoff.
+func (opts ConnOptions) ping(conn *sql.DB) error {
+	backoff := opts.ConnectBackoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	err := conn.Ping()
+	for i := 0; i < opts.ConnectRetries && err != nil; i++ {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > MaxConnectBackoff {
+			backoff = MaxConnectBackoff
+		}
+
+		err = conn.Ping()
+	}
+
+	return err
+}
+
+// withTimeout returns a copy of "ctx" which expires after the "timeout",
+// if it is positive and "ctx" has no deadline.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// readRetryBackoff is the wait before the first retry of a read, it grows linearly.
+const readRetryBackoff = 50 * time.Millisecond
+
+// retryRead calls "fn" and retries it up to "retries" times while it fails with a transient error.
+func retryRead(ctx context.Context, retries int, fn func() error) error {
+	err := fn()
+	for i := 1; i <= retries && isTransientError(err); i++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(i) * readRetryBackoff):
+		}
+
+		err = fn()
+	}
+
+	return err
+}
+
+// Transient MySQL and Postgres server errors, the same query may succeed on a retry.
+const (
+	mysqlErrTooManyConnections = 1040
+	mysqlErrServerShutdown     = 1053
+	mysqlErrLockWaitTimeout    = 1205
+	mysqlErrDeadlock           = 1213
+
+	pqErrSerializationFailure = "40001"
+	pqErrDeadlock             = "40P01"
+	pqErrTooManyConnections   = "53300"
+	pqErrAdminShutdown        = "57P01"
+)
+
+// isTransientError reports whether "err" is a connection error or a transient server error.
+func isTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if isConnError(err) {
+		return true
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrTooManyConnections, mysqlErrServerShutdown, mysqlErrLockWaitTimeout, mysqlErrDeadlock:
+			return true
+		}
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqErrSerializationFailure, pqErrDeadlock, pqErrTooManyConnections, pqErrAdminShutdown:
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,34 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err       error
+		transient bool
+	}{
+		{nil, false},
+		{errors.New("syntax error"), false},
+		{context.DeadlineExceeded, false},
+		{&mysql.MySQLError{Number: mysqlErrDeadlock}, true},
+		{fmt.Errorf("select: %w", &mysql.MySQLError{Number: mysqlErrLockWaitTimeout}), true},
+		{&mysql.MySQLError{Number: 1062}, false}, // duplicate entry.
+		{&pq.Error{Code: pqErrDeadlock}, true},
+		{fmt.Errorf("select: %w", &pq.Error{Code: pqErrSerializationFailure}), true},
+		{&pq.Error{Code: "23505"}, false}, // unique violation.
+	}
+
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.transient {
+			t.Fatalf("%v: expected transient to be %t but got %t", tt.err, tt.transient, got)
+		}
+	}
+}
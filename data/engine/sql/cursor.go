@@ -0,0 +1,193 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Cursor points to the last record of a list,
+// the next list starts right after it.
+// It holds the value of the sort column and the primary key of that record,
+// the value is nil if the sort column of that record is NULL.
+// Clients see it as an opaque token, see `EncodeCursor` and `DecodeCursor`.
+type Cursor struct {
+	Value interface{}
+	ID    int64
+}
+
+type cursorToken struct {
+	Value interface{} `json:"v"`
+	Time  bool        `json:"t,omitempty"` // Value is a time.Time.
+	ID    int64       `json:"id"`
+}
+
+// EncodeCursor returns the opaque token of "c".
+func EncodeCursor(c Cursor) string {
+	t := cursorToken{Value: c.Value, ID: c.ID}
+	if tm, ok := c.Value.(time.Time); ok {
+		t.Value = tm.Format(time.RFC3339Nano)
+		t.Time = true
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a token generated by `EncodeCursor`.
+func DecodeCursor(token string) (Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	var t cursorToken
+	if err = json.Unmarshal(b, &t); err != nil {
+		return Cursor{}, err
+	}
+
+	if t.Time {
+		s, ok := t.Value.(string)
+		if !ok {
+			return Cursor{}, fmt.Errorf("cursor: invalid time value: %v", t.Value)
+		}
+
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return Cursor{}, err
+		}
+		t.Value = tm
+	}
+
+	return Cursor{Value: t.Value, ID: t.ID}, nil
+}
+
+// keyset is the `Filter` which skips the records up to and including the cursor,
+// based on the sort column and the primary key as a tiebreak.
+type keyset struct {
+	Column     string
+	PrimaryKey string
+	Desc       bool
+	Cursor     Cursor
+}
+
+var _ Filter = keyset{}
+
+// Build renders the records after the cursor, the NULL values
+// come before the others on ascending order and after them on descending order,
+// see `Dialect.OrderNulls`.
+func (k keyset) Build() (string, []interface{}) {
+	op := ">"
+	if k.Desc {
+		op = "<"
+	}
+
+	if k.Column == "" || k.Column == k.PrimaryKey {
+		return fmt.Sprintf("%s %s ?", k.PrimaryKey, op), []interface{}{k.Cursor.ID}
+	}
+
+	if k.Cursor.Value == nil {
+		if k.Desc {
+			// only the rest of the NULLs.
+			return fmt.Sprintf("(%s IS NULL AND %s %s ?)", k.Column, k.PrimaryKey, op), []interface{}{k.Cursor.ID}
+		}
+
+		return fmt.Sprintf("(%s IS NOT NULL OR %s %s ?)", k.Column, k.PrimaryKey, op), []interface{}{k.Cursor.ID}
+	}
+
+	q := fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?)", k.Column, op, k.Column, k.PrimaryKey, op)
+	if k.Desc {
+		q += fmt.Sprintf(" OR %s IS NULL", k.Column)
+	}
+
+	return q + ")", []interface{}{k.Cursor.Value, k.Cursor.Value, k.Cursor.ID}
+}
+
+func (k keyset) Columns() []string {
+	if k.Column == "" {
+		return []string{k.PrimaryKey}
+	}
+
+	return []string{k.Column, k.PrimaryKey}
+}
+
+// NextCursor returns the cursor token of the last record of "dest"
+// or an empty string if "dest" is not a full list of "opts.Limit" records.
+// The "opts" should have their `OrderByColumn` and `PrimaryKey` already resolved.
+func NextCursor(dest interface{}, opts ListOptions) string {
+	if opts.Limit == 0 || opts.PrimaryKey == "" {
+		return ""
+	}
+
+	list := reflect.Indirect(reflect.ValueOf(dest))
+	if list.Kind() != reflect.Slice || uint64(list.Len()) < opts.Limit {
+		return ""
+	}
+
+	last := list.Index(list.Len() - 1)
+
+	id, ok := columnValue(last, opts.PrimaryKey)
+	if !ok || id == nil {
+		return ""
+	}
+
+	var c Cursor
+	switch id := reflect.ValueOf(id); id.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.ID = id.Int()
+	default:
+		return ""
+	}
+
+	if columns := splitColumns(opts.OrderByColumn); len(columns) > 0 && columns[0] != opts.PrimaryKey {
+		v, ok := columnValue(last, columns[0])
+		if !ok {
+			return ""
+		}
+		c.Value = v
+	}
+
+	return EncodeCursor(c)
+}
+
+// columnValue returns the value of the "column" field of the struct "v", nil if it is NULL.
+func columnValue(v reflect.Value, column string) (interface{}, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	index, ok := FieldsOf(v.Type())[column]
+	if !ok {
+		return nil, false
+	}
+
+	f := v.FieldByIndex(index)
+	if f.Kind() == reflect.Ptr {
+		if f.IsNil() {
+			return nil, true
+		}
+		f = f.Elem()
+	}
+
+	if valuer, ok := f.Interface().(driver.Valuer); ok {
+		// e.g. NullString, its value is nil if it is NULL.
+		value, err := valuer.Value()
+		return value, err == nil
+	}
+
+	return f.Interface(), true
+}
@@ -0,0 +1,182 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type cursorRecord struct {
+	ID        int64      `db:"id"`
+	Price     float64    `db:"price"`
+	Name      NullString `db:"name"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+func (cursorRecord) TableName() string  { return "items" }
+func (cursorRecord) PrimaryKey() string { return "id" }
+
+func TestCursorRoundTrip(t *testing.T) {
+	updated := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"primary key", Cursor{ID: 7}},
+		{"number", Cursor{Value: 10.5, ID: 7}},
+		{"text", Cursor{Value: "cairo", ID: 7}},
+		{"time", Cursor{Value: updated, ID: 7}},
+		{"null", Cursor{Value: nil, ID: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(EncodeCursor(tt.cursor))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.cursor) {
+				t.Fatalf("expected %#v but got %#v", tt.cursor, got)
+			}
+		})
+	}
+
+	if _, err := DecodeCursor("not a cursor"); err == nil {
+		t.Fatal("expected an invalid token to fail")
+	}
+}
+
+func TestNextCursor(t *testing.T) {
+	updated := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	list := []cursorRecord{
+		{ID: 1, Price: 5, UpdatedAt: &updated},
+		{ID: 2, Price: 10, Name: NewNullString("giza")},
+	}
+
+	tests := []struct {
+		name     string
+		by       string
+		limit    uint64
+		expected *Cursor // nil for no next page.
+	}{
+		{"last page", "price", 3, nil},
+		{"unlimited", "price", 0, nil},
+		{"primary key", "id", 2, &Cursor{ID: 2}},
+		{"number", "price", 2, &Cursor{Value: 10.0, ID: 2}},
+		{"valid nullable", "name", 2, &Cursor{Value: "giza", ID: 2}},
+		{"null pointer", "updated_at", 2, &Cursor{Value: nil, ID: 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := NextCursor(&list, ListOptions{OrderByColumn: tt.by, PrimaryKey: "id", Limit: tt.limit})
+			if tt.expected == nil {
+				if token != "" {
+					t.Fatalf("expected no next cursor but got %q", token)
+				}
+				return
+			}
+
+			got, err := DecodeCursor(token)
+			if err != nil {
+				t.Fatalf("invalid next cursor %q: %v", token, err)
+			}
+			if !reflect.DeepEqual(got, *tt.expected) {
+				t.Fatalf("expected %#v but got %#v", *tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyset   keyset
+		expected string
+		args     []interface{}
+	}{
+		{
+			"primary key",
+			keyset{PrimaryKey: "id", Cursor: Cursor{ID: 7}},
+			"id > ?", []interface{}{int64(7)},
+		},
+		{
+			"primary key descending",
+			keyset{PrimaryKey: "id", Desc: true, Cursor: Cursor{ID: 7}},
+			"id < ?", []interface{}{int64(7)},
+		},
+		{
+			"ascending",
+			keyset{Column: "price", PrimaryKey: "id", Cursor: Cursor{Value: 10.0, ID: 7}},
+			"(price > ? OR (price = ? AND id > ?))", []interface{}{10.0, 10.0, int64(7)},
+		},
+		{
+			"descending, the NULLs come last",
+			keyset{Column: "price", PrimaryKey: "id", Desc: true, Cursor: Cursor{Value: 10.0, ID: 7}},
+			"(price < ? OR (price = ? AND id < ?) OR price IS NULL)", []interface{}{10.0, 10.0, int64(7)},
+		},
+		{
+			"ascending after a NULL",
+			keyset{Column: "price", PrimaryKey: "id", Cursor: Cursor{ID: 7}},
+			"(price IS NOT NULL OR id > ?)", []interface{}{int64(7)},
+		},
+		{
+			"descending after a NULL",
+			keyset{Column: "price", PrimaryKey: "id", Desc: true, Cursor: Cursor{ID: 7}},
+			"(price IS NULL AND id < ?)", []interface{}{int64(7)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args := tt.keyset.Build()
+			if q != tt.expected {
+				t.Fatalf("expected query %q but got %q", tt.expected, q)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("expected args %v but got %v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestBuildQueryCursor(t *testing.T) {
+	cursor := EncodeCursor(Cursor{Value: 10.0, ID: 7})
+
+	tests := []struct {
+		name     string
+		opts     ListOptions
+		expected string
+	}{
+		{
+			"offset",
+			ListOptions{Table: "items", PrimaryKey: "id", OrderByColumn: "price", Limit: 2, Offset: 4},
+			"SELECT * FROM items ORDER BY price ASC, id ASC LIMIT 2 OFFSET 4",
+		},
+		{
+			"cursor wins over offset",
+			ListOptions{Table: "items", PrimaryKey: "id", OrderByColumn: "price", Limit: 2, Offset: 4, Cursor: cursor},
+			"SELECT * FROM items WHERE (price > ? OR (price = ? AND id > ?)) ORDER BY price ASC, id ASC LIMIT 2",
+		},
+		{
+			"postgres sorts the NULLs first",
+			ListOptions{Table: "items", PrimaryKey: "id", OrderByColumn: "price", Limit: 2, Cursor: cursor, Dialect: PostgresDialect},
+			"SELECT * FROM items WHERE (price > $1 OR (price = $2 AND id > $3)) ORDER BY price ASC NULLS FIRST, id ASC LIMIT 2",
+		},
+		{
+			"postgres sorts the NULLs last on descending order",
+			ListOptions{Table: "items", PrimaryKey: "id", OrderByColumn: "price", Order: "desc", Limit: 2, Dialect: PostgresDialect},
+			"SELECT * FROM items ORDER BY price DESC NULLS LAST, id DESC LIMIT 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if q, _ := tt.opts.BuildQuery(); q != tt.expected {
+				t.Fatalf("expected query:\n%s\nbut got:\n%s", tt.expected, q)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect renders the database specific parts of the generated SQL.
+// Queries are written with "?" placeholders and converted through `Rebind`.
+type Dialect interface {
+	// Name returns the name of the dialect, e.g. "mysql".
+	Name() string
+	// Rebind converts the "?" placeholders of "q" to the dialect's ones.
+	Rebind(q string) string
+	// DeleteLimit returns the clause which limits a DELETE to "n" rows
+	// or an empty string if the database does not support it.
+	DeleteLimit(n int) string
+	// Returning returns the clause which makes an INSERT return the "column"
+	// or an empty string if the database does not support it,
+	// in that case the id is read from `sql.Result.LastInsertId`.
+	Returning(column string) string
+	// Upsert returns the clause which makes an INSERT of the "table" update the row
+	// which conflicts on the unique "keys": its "columns" are set to the inserted values
+	// and its "counters" are incremented. The row is kept as it is if both are empty.
+	Upsert(table string, keys, columns, counters []string) string
+	// OrderNulls returns the clause of an ORDER BY column which sorts its NULL values
+	// before the others on ascending order and after them on descending order,
+	// or an empty string if the database does so by default.
+	OrderNulls(desc bool) string
+}
+
+// Dialecter is implemented by the databases which are not MySQL-compatible.
+type Dialecter interface {
+	Dialect() Dialect
+}
+
+var (
+	// MySQLDialect is the dialect of MySQL and MariaDB, it is the default one.
+	MySQLDialect Dialect = mysqlDialect{}
+	// PostgresDialect is the dialect of PostgreSQL.
+	PostgresDialect Dialect = postgresDialect{}
+)
+
+// DialectOf returns the dialect of the "db", defaults to `MySQLDialect`.
+func DialectOf(db Database) Dialect {
+	if d, ok := db.(Dialecter); ok {
+		return d.Dialect()
+	}
+
+	return MySQLDialect
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Rebind(q string) string {
+	return q
+}
+
+func (mysqlDialect) DeleteLimit(n int) string {
+	return fmt.Sprintf(" LIMIT %d", n)
+}
+
+func (mysqlDialect) Returning(column string) string {
+	return ""
+}
+
+// Upsert returns an ON DUPLICATE KEY UPDATE clause,
+// it applies to any unique index of the table, not only the "keys".
+func (mysqlDialect) Upsert(table string, keys, columns, counters []string) string {
+	var sets []string
+	for _, c := range columns {
+		sets = append(sets, fmt.Sprintf("%s = VALUES(%s)", c, c))
+	}
+	for _, c := range counters {
+		sets = append(sets, fmt.Sprintf("%s = %s + 1", c, c))
+	}
+
+	if len(sets) == 0 {
+		// a no-op update, unlike INSERT IGNORE it does not hide the other errors.
+		sets = append(sets, fmt.Sprintf("%s = %s", keys[0], keys[0]))
+	}
+
+	return " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+// OrderNulls returns an empty string, MySQL sorts the NULLs as the lowest values.
+func (mysqlDialect) OrderNulls(desc bool) string {
+	return ""
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+// Rebind converts "?" to "$1", "$2"... placeholders,
+// question marks inside quoted strings and identifiers are kept.
+func (postgresDialect) Rebind(q string) string {
+	var (
+		b     strings.Builder
+		n     int
+		quote rune
+	)
+
+	b.Grow(len(q) + 8)
+	for _, r := range q {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func (postgresDialect) DeleteLimit(n int) string {
+	return ""
+}
+
+func (postgresDialect) Returning(column string) string {
+	return " RETURNING " + column
+}
+
+// Upsert returns an ON CONFLICT clause, the "keys" should be covered by a unique index.
+func (postgresDialect) Upsert(table string, keys, columns, counters []string) string {
+	var sets []string
+	for _, c := range columns {
+		sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+	}
+	for _, c := range counters {
+		sets = append(sets, fmt.Sprintf("%s = %s.%s + 1", c, table, c))
+	}
+
+	clause := fmt.Sprintf(" ON CONFLICT (%s) DO ", strings.Join(keys, ", "))
+	if len(sets) == 0 {
+		return clause + "NOTHING"
+	}
+
+	return clause + "UPDATE SET " + strings.Join(sets, ", ")
+}
+
+// OrderNulls returns a NULLS FIRST or NULLS LAST clause,
+// PostgreSQL sorts the NULLs as the highest values by default.
+func (postgresDialect) OrderNulls(desc bool) string {
+	if desc {
+		return " NULLS LAST"
+	}
+
+	return " NULLS FIRST"
+}
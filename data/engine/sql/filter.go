@@ -0,0 +1,290 @@
+package sql
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Operator is a comparison operator of a filter `Condition`.
+type Operator string
+
+// The supported filter operators.
+// Their names are used on the URL query syntax too, e.g. ?price[gte]=10.
+const (
+	OpEq     Operator = "eq"
+	OpNe     Operator = "ne"
+	OpGt     Operator = "gt"
+	OpGte    Operator = "gte"
+	OpLt     Operator = "lt"
+	OpLte    Operator = "lte"
+	OpIn     Operator = "in"
+	OpNotIn  Operator = "nin"
+	OpLike   Operator = "like"
+	OpIsNull Operator = "null" // value is a bool, false renders IS NOT NULL.
+)
+
+var comparisons = map[Operator]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+// Filter is a node of a WHERE expression,
+// either a single `Condition` or a `Group` of filters.
+type Filter interface {
+	// Build returns the parameterised SQL of the filter and its arguments.
+	Build() (string, []interface{})
+	// Columns returns the column names the filter refers to.
+	Columns() []string
+}
+
+// Condition is a filter of a single column, e.g. price >= 10.
+type Condition struct {
+	Column string
+	Op     Operator
+	Value  interface{}
+}
+
+var _ Filter = Condition{}
+
+// Build implements the `Filter` interface.
+func (c Condition) Build() (string, []interface{}) {
+	switch c.Op {
+	case OpIn, OpNotIn:
+		values := toSlice(c.Value)
+		if len(values) == 0 {
+			if c.Op == OpIn {
+				return "1 = 0", nil // nothing matches an empty list.
+			}
+			return "1 = 1", nil
+		}
+
+		op := "IN"
+		if c.Op == OpNotIn {
+			op = "NOT IN"
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+		return fmt.Sprintf("%s %s (%s)", c.Column, op, placeholders), values
+	case OpLike:
+		return fmt.Sprintf("%s LIKE ?", c.Column), []interface{}{c.Value}
+	case OpIsNull:
+		if isNull, ok := c.Value.(bool); ok && !isNull {
+			return fmt.Sprintf("%s IS NOT NULL", c.Column), nil
+		}
+		return fmt.Sprintf("%s IS NULL", c.Column), nil
+	default:
+		op, ok := comparisons[c.Op]
+		if !ok {
+			op = comparisons[OpEq]
+		}
+		return fmt.Sprintf("%s %s ?", c.Column, op), []interface{}{c.Value}
+	}
+}
+
+// Columns implements the `Filter` interface.
+func (c Condition) Columns() []string {
+	return []string{c.Column}
+}
+
+// Group joins one or more filters with AND or OR.
+type Group struct {
+	Or      bool // joins the filters with OR instead of AND.
+	Filters []Filter
+}
+
+var _ Filter = Group{}
+
+// Build implements the `Filter` interface.
+// An empty group renders an empty string.
+func (g Group) Build() (string, []interface{}) {
+	var (
+		parts []string
+		args  []interface{}
+	)
+
+	for _, f := range g.Filters {
+		if f == nil {
+			continue
+		}
+
+		q, fargs := f.Build()
+		if q == "" {
+			continue
+		}
+
+		parts = append(parts, q)
+		args = append(args, fargs...)
+	}
+
+	switch len(parts) {
+	case 0:
+		return "", nil
+	case 1:
+		return parts[0], args
+	}
+
+	sep := " AND "
+	if g.Or {
+		sep = " OR "
+	}
+
+	return "(" + strings.Join(parts, sep) + ")", args
+}
+
+// Columns implements the `Filter` interface.
+func (g Group) Columns() (columns []string) {
+	for _, f := range g.Filters {
+		if f != nil {
+			columns = append(columns, f.Columns()...)
+		}
+	}
+
+	return
+}
+
+// And returns a group of filters joined with AND.
+func And(filters ...Filter) Group {
+	return Group{Filters: filters}
+}
+
+// Or returns a group of filters joined with OR.
+func Or(filters ...Filter) Group {
+	return Group{Or: true, Filters: filters}
+}
+
+// Eq returns a column = value condition.
+func Eq(column string, value interface{}) Condition {
+	return Condition{Column: column, Op: OpEq, Value: value}
+}
+
+// In returns a column IN (values...) condition.
+func In(column string, values ...interface{}) Condition {
+	return Condition{Column: column, Op: OpIn, Value: values}
+}
+
+// Like returns a column LIKE pattern condition.
+func Like(column string, pattern string) Condition {
+	return Condition{Column: column, Op: OpLike, Value: pattern}
+}
+
+// IsNull returns a column IS NULL condition.
+func IsNull(column string) Condition {
+	return Condition{Column: column, Op: OpIsNull, Value: true}
+}
+
+func toSlice(v interface{}) []interface{} {
+	switch values := v.(type) {
+	case []interface{}:
+		return values
+	case []string:
+		s := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			s = append(s, value)
+		}
+		return s
+	case []int64:
+		s := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			s = append(s, value)
+		}
+		return s
+	case nil:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
+
+// parseFilters collects the column[op]=value parameters of "q", e.g.
+// ?price[gte]=10&price[lt]=100&category_id[in]=1,2&name_en[like]=cairo&parent_id[null]=true
+// The conditions are joined with AND, or with OR if ?match=any is present.
+// Malformed parameters are ignored.
+func parseFilters(q url.Values) Filter {
+	var group Group
+	group.Or = strings.EqualFold(q.Get("match"), "any")
+
+	keys := make([]string, 0, len(q))
+	for key := range q {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // keep the same query for the same parameters.
+
+	for _, key := range keys {
+		column, op, ok := parseFilterKey(key)
+		if !ok {
+			continue
+		}
+
+		for _, value := range q[key] {
+			c := Condition{Column: column, Op: op, Value: value}
+
+			switch op {
+			case OpIn, OpNotIn:
+				c.Value = toSlice(strings.Split(value, ","))
+			case OpLike:
+				c.Value = "%" + escapeLike(value) + "%"
+			case OpIsNull:
+				isNull, err := strconv.ParseBool(value)
+				if err != nil {
+					isNull = value == ""
+				}
+				c.Value = isNull
+			}
+
+			group.Filters = append(group.Filters, c)
+		}
+	}
+
+	if len(group.Filters) == 0 {
+		return nil
+	}
+
+	return group
+}
+
+// parseFilterKey splits a column[op] key.
+func parseFilterKey(key string) (string, Operator, bool) {
+	start := strings.IndexByte(key, '[')
+	if start <= 0 || !strings.HasSuffix(key, "]") {
+		return "", "", false
+	}
+
+	column, op := key[:start], Operator(key[start+1:len(key)-1])
+	if !isIdentifier(column) {
+		return "", "", false
+	}
+
+	switch op {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte, OpIn, OpNotIn, OpLike, OpIsNull:
+		return column, op, true
+	default:
+		return "", "", false
+	}
+}
+
+// isIdentifier reports whether "s" is a plain column name: letters, digits and underscores.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
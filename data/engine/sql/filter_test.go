@@ -0,0 +1,103 @@
+package sql
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		args     []interface{}
+	}{
+		{
+			"none",
+			"by=price&limit=10",
+			"SELECT * FROM items ORDER BY price ASC LIMIT 10", nil,
+		},
+		{
+			"comparison",
+			"price[gte]=10",
+			"SELECT * FROM items WHERE price >= ?", []interface{}{"10"},
+		},
+		{
+			"range",
+			"price[gte]=10&price[lt]=100",
+			"SELECT * FROM items WHERE (price >= ? AND price < ?)", []interface{}{"10", "100"},
+		},
+		{
+			"in, sorted by the keys",
+			"price[gte]=10&category_id[in]=1,2",
+			"SELECT * FROM items WHERE (category_id IN (?,?) AND price >= ?)", []interface{}{"1", "2", "10"},
+		},
+		{
+			"not in",
+			"category_id[nin]=3",
+			"SELECT * FROM items WHERE category_id NOT IN (?)", []interface{}{"3"},
+		},
+		{
+			"any",
+			"name_en[like]=cairo&name_ar[like]=cairo&match=any",
+			"SELECT * FROM items WHERE (name_ar LIKE ? OR name_en LIKE ?)", []interface{}{"%cairo%", "%cairo%"},
+		},
+		{
+			"like escapes its wildcards",
+			"name_en[like]=50%25_off",
+			`SELECT * FROM items WHERE name_en LIKE ?`, []interface{}{`%50\%\_off%`},
+		},
+		{
+			"null",
+			"parent_id[null]=true&deleted_at[null]=false",
+			"SELECT * FROM items WHERE (deleted_at IS NOT NULL AND parent_id IS NULL)", nil,
+		},
+		{
+			"null without a value",
+			"parent_id[null]=",
+			"SELECT * FROM items WHERE parent_id IS NULL", nil,
+		},
+		{
+			"not equal",
+			"status[ne]=closed",
+			"SELECT * FROM items WHERE status <> ?", []interface{}{"closed"},
+		},
+		{
+			"malformed parameters are ignored",
+			"price[between]=1,2&price]=1&[eq]=1&a-b[eq]=1&price[eq=1",
+			"SELECT * FROM items", nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := ParseListOptions(q)
+			opts.Table = "items"
+			got, args := opts.BuildQuery()
+			if got != tt.expected {
+				t.Fatalf("expected query:\n%s\nbut got:\n%s", tt.expected, got)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Fatalf("expected args %v but got %v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestParseListOptionsPagination(t *testing.T) {
+	q, _ := url.ParseQuery("offset=20&limit=10&cursor=abc&include=category,ratings.user")
+	opts := ParseListOptions(q)
+
+	if opts.Offset != 0 || opts.Cursor != "abc" || opts.Limit != 10 {
+		t.Fatalf("expected the cursor to win over the offset but got offset %d and cursor %q", opts.Offset, opts.Cursor)
+	}
+	if expected := []string{"category", "ratings.user"}; !reflect.DeepEqual(opts.Include, expected) {
+		t.Fatalf("expected include %v but got %v", expected, opts.Include)
+	}
+}
@@ -0,0 +1,159 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Records can optionally implement the following hook interfaces
+// to normalise and validate their data before they are written
+// or to act after they are stored or removed.
+// A non-nil error returned by a hook cancels the operation.
+type (
+	// BeforeInsertHook is called after the timestamps are set and before the record is inserted.
+	BeforeInsertHook interface {
+		BeforeInsert(ctx context.Context) error
+	}
+
+	// AfterInsertHook is called after the record is inserted and its primary key is set.
+	AfterInsertHook interface {
+		AfterInsert(ctx context.Context) error
+	}
+
+	// BeforeUpdateHook is called after the "updated_at" timestamp is set and before the record is updated.
+	BeforeUpdateHook interface {
+		BeforeUpdate(ctx context.Context) error
+	}
+
+	// AfterDeleteHook is called on the repository's record after the "id" record was deleted.
+	AfterDeleteHook interface {
+		AfterDelete(ctx context.Context, id int64) error
+	}
+)
+
+const (
+	// CreatedAtColumn is the column which is set to the current time on insert.
+	CreatedAtColumn = "created_at"
+	// UpdatedAtColumn is the column which is set to the current time on insert and update.
+	UpdatedAtColumn = "updated_at"
+)
+
+// BeforeInsert sets the zero timestamps and the zero integer version of "rec",
+// a pointer to a record, and calls its `BeforeInsertHook`, if any.
+func BeforeInsert(ctx context.Context, rec interface{}) error {
+	now := time.Now()
+	Touch(rec, CreatedAtColumn, now, false)
+	Touch(rec, UpdatedAtColumn, now, false)
+	if f, ok := versionField(rec); ok && isZeroInt(f) {
+		f.SetInt(1)
+	}
+
+	if hook, ok := rec.(BeforeInsertHook); ok {
+		return hook.BeforeInsert(ctx)
+	}
+
+	return nil
+}
+
+// AfterInsert sets the "id" primary key of "rec", a pointer to a record,
+// when it is not already set and calls its `AfterInsertHook`, if any.
+func AfterInsert(ctx context.Context, rec interface{}, id int64) error {
+	if r, ok := rec.(Record); ok && id > 0 {
+		if f, ok := fieldOf(rec, r.PrimaryKey()); ok && isZeroInt(f) {
+			f.SetInt(id)
+		}
+	}
+
+	if hook, ok := rec.(AfterInsertHook); ok {
+		return hook.AfterInsert(ctx)
+	}
+
+	return nil
+}
+
+// BeforeUpdate sets the "updated_at" timestamp of "rec", a pointer to a record,
+// and calls its `BeforeUpdateHook`, if any.
+// When "updated_at" is the `Versioned` column it is left untouched,
+// it holds the version the caller has read and it is set on the update itself.
+func BeforeUpdate(ctx context.Context, rec interface{}) error {
+	if r, ok := rec.(Record); !ok || VersionColumnOf(r) != UpdatedAtColumn {
+		Touch(rec, UpdatedAtColumn, time.Now(), true)
+	}
+
+	if hook, ok := rec.(BeforeUpdateHook); ok {
+		return hook.BeforeUpdate(ctx)
+	}
+
+	return nil
+}
+
+// AfterDelete calls the `AfterDeleteHook` of "rec", if any.
+func AfterDelete(ctx context.Context, rec interface{}, id int64) error {
+	if hook, ok := rec.(AfterDeleteHook); ok {
+		return hook.AfterDelete(ctx, id)
+	}
+
+	return nil
+}
+
+// Touch sets the time "column" of "rec", a pointer to a record, to "now".
+// If "force" is false then it is set only when it is zero.
+// Columns of type time.Time, *time.Time and `NullTime` are supported.
+func Touch(rec interface{}, column string, now time.Time, force bool) {
+	f, ok := fieldOf(rec, column)
+	if !ok {
+		return
+	}
+
+	switch t := f.Interface().(type) {
+	case *time.Time:
+		if force || t == nil {
+			f.Set(reflect.ValueOf(&now))
+		}
+	case time.Time:
+		if force || t.IsZero() {
+			f.Set(reflect.ValueOf(now))
+		}
+	case NullTime:
+		if force || !t.Valid {
+			f.Set(reflect.ValueOf(NewNullTime(now)))
+		}
+	}
+}
+
+// PrimaryKeyOf returns the integer primary key of "rec", a pointer to a record,
+// or zero if it is not set.
+func PrimaryKeyOf(rec interface{}) int64 {
+	r, ok := rec.(Record)
+	if !ok {
+		return 0
+	}
+
+	f, ok := fieldOf(rec, r.PrimaryKey())
+	if !ok || !isIntKind(f.Kind()) {
+		return 0
+	}
+
+	return f.Int()
+}
+
+// fieldOf returns the settable field of the "column" of "rec", a pointer to a struct.
+func fieldOf(rec interface{}, column string) (reflect.Value, bool) {
+	v := reflect.ValueOf(rec)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	v = v.Elem()
+
+	index, ok := FieldsOf(v.Type())[column]
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	return v.FieldByIndex(index), true
+}
+
+func isZeroInt(f reflect.Value) bool {
+	return isIntKind(f.Kind()) && f.Int() == 0
+}
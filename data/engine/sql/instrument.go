@@ -0,0 +1,390 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryEntry describes a query which run through an `Instrumented` database.
+type QueryEntry struct {
+	RequestID string        `json:"request_id,omitempty"`
+	Query     string        `json:"query"`
+	Args      []interface{} `json:"args,omitempty"` // secrets are redacted, see `InstrumentOptions.SecretColumns`.
+	Duration  time.Duration `json:"duration"`
+	Rows      int64         `json:"rows"` // the rows read by `Select` and `Get`, the affected ones by `Exec`.
+	Err       error         `json:"-"`
+	Slow      bool          `json:"slow,omitempty"`
+}
+
+// String returns a single line text of the entry, e.g. for a log file.
+func (e QueryEntry) String() string {
+	var b strings.Builder
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, "[%s] ", e.RequestID)
+	}
+	fmt.Fprintf(&b, "%s (%s, %d rows)", e.Query, e.Duration, e.Rows)
+	if len(e.Args) > 0 {
+		fmt.Fprintf(&b, " args: %v", e.Args)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&b, " error: %v", e.Err)
+	}
+
+	return b.String()
+}
+
+// QueryStats holds the aggregated statistics of the queries of the same shape,
+// that is the query text without its literal values, see `QueryShape`.
+type QueryStats struct {
+	Shape  string        `json:"shape"`
+	Count  int64         `json:"count"`
+	Errors int64         `json:"errors"`
+	Slow   int64         `json:"slow"`
+	Rows   int64         `json:"rows"`
+	Total  time.Duration `json:"total"`
+	Mean   time.Duration `json:"mean"`
+	Max    time.Duration `json:"max"`
+}
+
+// InstrumentOptions holds the settings of an `Instrumented` database, all fields are optional.
+type InstrumentOptions struct {
+	// Log receives every query, e.g. to print them on development.
+	Log func(QueryEntry)
+	// SlowThreshold is the duration over which a query is slow.
+	// Zero disables the slow query log.
+	SlowThreshold time.Duration
+	// SlowLog receives the slow queries.
+	SlowLog func(QueryEntry)
+	// RequestID returns the id of the request which runs the query,
+	// e.g. the one of the iris requestid middleware.
+	RequestID func(ctx context.Context) string
+	// SecretColumns are the columns whose arguments are redacted,
+	// defaults to `DefaultSecretColumns`. Byte slice arguments are always redacted.
+	SecretColumns []string
+}
+
+// DefaultSecretColumns are the default `InstrumentOptions.SecretColumns`.
+var DefaultSecretColumns = []string{"hashpass", "password", "token", "secret"}
+
+// Redacted replaces the secret arguments of a `QueryEntry`.
+const Redacted = "[REDACTED]"
+
+// Instrumented is a `Database` decorator which records every query:
+// its text, its redacted arguments, its duration, the number of rows and its error.
+// The entries are passed to the `InstrumentOptions.Log` and `SlowLog` functions
+// and aggregated by their shape, see `Stats`.
+// See the `Instrument` package-level function.
+type Instrumented struct {
+	db    Database
+	opts  InstrumentOptions
+	stats *queryStats // shared with the transactions.
+}
+
+var (
+	_ Database      = (*Instrumented)(nil)
+	_ Transactional = (*Instrumented)(nil)
+	_ Dialecter     = (*Instrumented)(nil)
+	_ Committer     = (*Instrumented)(nil)
+	_ Pinger        = (*Instrumented)(nil)
+)
+
+// Instrument returns a new `Instrumented` database which records the queries of "db".
+func Instrument(db Database, opts InstrumentOptions) *Instrumented {
+	if opts.SecretColumns == nil {
+		opts.SecretColumns = DefaultSecretColumns
+	}
+
+	return &Instrumented{db: db, opts: opts, stats: &queryStats{shapes: make(map[string]*QueryStats)}}
+}
+
+// Unwrap returns the decorated database.
+func (db *Instrumented) Unwrap() Database {
+	return db.db
+}
+
+// Select performs the SELECT query and records it.
+func (db *Instrumented) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	start := time.Now()
+	err := db.db.Select(ctx, dest, query, args...)
+	db.record(ctx, query, args, start, countRows(dest, err), err)
+	return err
+}
+
+// Get same as `Select` but it moves the cursor to the first result.
+func (db *Instrumented) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	start := time.Now()
+	err := db.db.Get(ctx, dest, query, args...)
+
+	var rows int64
+	if err == nil {
+		rows = 1
+	}
+	db.record(ctx, query, args, start, rows, err)
+	return err
+}
+
+// Exec executes the query and records it along with the affected rows.
+func (db *Instrumented) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.db.Exec(ctx, query, args...)
+	db.record(ctx, query, args, start, int64(GetAffectedRows(res)), err)
+	return res, err
+}
+
+// WithTx runs "fn" inside a transaction of the decorated database,
+// the queries of the transaction are recorded too.
+// Returns `ErrTxUnsupported` if the database does not support transactions.
+func (db *Instrumented) WithTx(ctx context.Context, fn func(tx Database) error) error {
+	t, ok := db.db.(Transactional)
+	if !ok {
+		return ErrTxUnsupported
+	}
+
+	return t.WithTx(ctx, func(tx Database) error {
+		return fn(&Instrumented{db: tx, opts: db.opts, stats: db.stats})
+	})
+}
+
+// AfterCommit implements the `Committer` interface, see the `AfterCommit` function.
+func (db *Instrumented) AfterCommit(fn func()) {
+	AfterCommit(db.db, fn)
+}
+
+// Dialect implements the `Dialecter` interface, it returns the dialect of the decorated database.
+func (db *Instrumented) Dialect() Dialect {
+	return DialectOf(db.db)
+}
+
+// Ping implements the `Pinger` interface.
+func (db *Instrumented) Ping(ctx context.Context) error {
+	if p, ok := db.db.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+// Close closes the decorated database.
+func (db *Instrumented) Close() error {
+	if c, ok := db.db.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// Stats returns the statistics of the recorded queries by their shape,
+// the most time consuming first.
+func (db *Instrumented) Stats() []QueryStats {
+	return db.stats.list()
+}
+
+// ResetStats clears the statistics of the recorded queries.
+func (db *Instrumented) ResetStats() {
+	db.stats.reset()
+}
+
+func (db *Instrumented) record(ctx context.Context, query string, args []interface{}, start time.Time, rows int64, err error) {
+	if err == ErrNoRows {
+		err = nil // not a failure of the query.
+	}
+
+	entry := QueryEntry{
+		Query:    query,
+		Duration: time.Since(start),
+		Rows:     rows,
+		Err:      err,
+	}
+	entry.Slow = db.opts.SlowThreshold > 0 && entry.Duration >= db.opts.SlowThreshold
+
+	db.stats.add(entry)
+
+	if db.opts.Log == nil && (!entry.Slow || db.opts.SlowLog == nil) {
+		return
+	}
+
+	if db.opts.RequestID != nil && ctx != nil {
+		entry.RequestID = db.opts.RequestID(ctx)
+	}
+	entry.Args = redactArgs(query, args, db.opts.SecretColumns)
+
+	if db.opts.Log != nil {
+		db.opts.Log(entry)
+	}
+
+	if entry.Slow && db.opts.SlowLog != nil {
+		db.opts.SlowLog(entry)
+	}
+}
+
+// countRows returns the length of the "dest" slice or 1 for a single record.
+func countRows(dest interface{}, err error) int64 {
+	if err != nil || dest == nil {
+		return 0
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(dest))
+	if v.Kind() == reflect.Slice {
+		if _, ok := dest.(sql.Scanner); !ok {
+			return int64(v.Len())
+		}
+	}
+
+	return 1
+}
+
+type queryStats struct {
+	mu     sync.Mutex
+	shapes map[string]*QueryStats
+}
+
+func (s *queryStats) add(e QueryEntry) {
+	shape := QueryShape(e.Query)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.shapes[shape]
+	if !ok {
+		st = &QueryStats{Shape: shape}
+		s.shapes[shape] = st
+	}
+
+	st.Count++
+	st.Rows += e.Rows
+	st.Total += e.Duration
+	st.Mean = st.Total / time.Duration(st.Count)
+	if e.Duration > st.Max {
+		st.Max = e.Duration
+	}
+	if e.Err != nil {
+		st.Errors++
+	}
+	if e.Slow {
+		st.Slow++
+	}
+}
+
+func (s *queryStats) list() []QueryStats {
+	s.mu.Lock()
+	list := make([]QueryStats, 0, len(s.shapes))
+	for _, st := range s.shapes {
+		list = append(list, *st)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Total == list[j].Total {
+			return list[i].Shape < list[j].Shape
+		}
+		return list[i].Total > list[j].Total
+	})
+
+	return list
+}
+
+func (s *queryStats) reset() {
+	s.mu.Lock()
+	s.shapes = make(map[string]*QueryStats)
+	s.mu.Unlock()
+}
+
+var (
+	shapeLiterals     = regexp.MustCompile(`'(?:[^']|'')*'|\$\d+|\b\d+(?:\.\d+)?\b`)
+	shapeLists        = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	shapeValues       = regexp.MustCompile(`\(\.\.\.\)(?:\s*,\s*\(\.\.\.\))+`)
+	shapeSpaces       = regexp.MustCompile(`\s+`)
+	placeholderColumn = regexp.MustCompile(`(?i)([a-z_][a-z0-9_]*)\s*(?:=|<>|!=|<=|>=|<|>|\s+like)\s*$`)
+	insertColumns     = regexp.MustCompile(`(?is)^\s*insert\s+into\s+\S+\s*\(([^)]*)\)\s*values`)
+)
+
+// QueryShape returns the "query" without its literal values,
+// so the same query with different values has the same shape, e.g.
+// "SELECT * FROM products WHERE id = 1 LIMIT 1" is "SELECT * FROM products WHERE id = ? LIMIT ?".
+// Placeholder lists, e.g. of IN or multi-row VALUES, are collapsed to "(...)".
+func QueryShape(query string) string {
+	q := shapeLiterals.ReplaceAllString(query, "?")
+	q = shapeLists.ReplaceAllString(q, "(...)")
+	q = shapeValues.ReplaceAllString(q, "(...)")
+	q = shapeSpaces.ReplaceAllString(strings.TrimSpace(q), " ")
+	return strings.TrimSuffix(q, ";")
+}
+
+// redactArgs returns a copy of "args" where the arguments of the "secrets" columns
+// and the byte slices are replaced with `Redacted`.
+func redactArgs(query string, args []interface{}, secrets []string) []interface{} {
+	if len(args) == 0 {
+		return nil
+	}
+
+	columns := placeholderColumns(query, len(args))
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch {
+		case containsString(secrets, strings.ToLower(columns[i])):
+			redacted[i] = Redacted
+		default:
+			if _, ok := arg.([]byte); ok {
+				redacted[i] = Redacted
+			} else {
+				redacted[i] = arg
+			}
+		}
+	}
+
+	return redacted
+}
+
+// placeholderColumns returns the column of each of the "n" arguments of the "query",
+// that is the column of its INSERT list or the column it is compared to, otherwise empty.
+// Both "?" and "$N" placeholders are supported.
+func placeholderColumns(query string, n int) []string {
+	columns := make([]string, n)
+
+	var inserted []string
+	valuesAt := 0
+	if m := insertColumns.FindStringSubmatchIndex(query); m != nil {
+		for _, c := range strings.Split(query[m[2]:m[3]], ",") {
+			inserted = append(inserted, strings.TrimSpace(c))
+		}
+		valuesAt = m[1]
+	}
+
+	arg, quoted := 0, false
+	for i := 0; i < len(query) && arg < n; i++ {
+		switch c := query[i]; {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '?' || (c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9'):
+			index := arg
+			if c == '$' {
+				j := i + 1
+				for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+					j++
+				}
+				index, _ = strconv.Atoi(query[i+1 : j])
+				index--
+			}
+
+			if index >= 0 && index < n {
+				if len(inserted) > 0 && i >= valuesAt {
+					columns[index] = inserted[arg%len(inserted)]
+				} else if m := placeholderColumn.FindStringSubmatch(query[:i]); m != nil {
+					columns[index] = m[1]
+				}
+			}
+			arg++
+		}
+	}
+
+	return columns
+}
@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// StringList is a list of strings stored as a JSON array,
+// e.g. on a MySQL `JSON` column. It implements the `driver.Valuer` and `sql.Scanner` interfaces.
+// A nil list is stored as an empty array and NULL is read as an empty list.
+type StringList []string
+
+var (
+	_ driver.Valuer = StringList(nil)
+	_ driver.Valuer = JSONObject(nil)
+)
+
+// Value implements the `driver.Valuer` interface.
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+
+	b, err := json.Marshal([]string(l))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the `sql.Scanner` interface.
+func (l *StringList) Scan(src interface{}) error {
+	var list []string
+	if err := scanJSON(src, &list); err != nil {
+		return err
+	}
+
+	if list == nil {
+		list = []string{}
+	}
+
+	*l = list
+	return nil
+}
+
+// JSONObject is a generic JSON object, e.g. on a MySQL `JSON` column.
+// It implements the `driver.Valuer` and `sql.Scanner` interfaces.
+// A nil object is stored as NULL.
+type JSONObject map[string]interface{}
+
+// Value implements the `driver.Valuer` interface.
+func (o JSONObject) Value() (driver.Value, error) {
+	if o == nil {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(map[string]interface{}(o))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// Scan implements the `sql.Scanner` interface.
+func (o *JSONObject) Scan(src interface{}) error {
+	var obj map[string]interface{}
+	if err := scanJSON(src, &obj); err != nil {
+		return err
+	}
+
+	*o = obj
+	return nil
+}
+
+// scanJSON decodes the JSON "src" column value to "dest". NULL is a no-op.
+func scanJSON(src interface{}, dest interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("sql: cannot scan %T into a JSON column", src)
+	}
+
+	if len(b) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(b, dest)
+}
@@ -12,9 +12,15 @@ import (
 // See the `ConnectMySQL` package-level function.
 type MySQL struct {
 	Conn *sql.DB
+	opts ConnOptions // see `ConnectMySQLWith`.
 }
 
-var _ Database = (*MySQL)(nil)
+var (
+	_ Database      = (*MySQL)(nil)
+	_ Transactional = (*MySQL)(nil)
+	_ Dialecter     = (*MySQL)(nil)
+	_ Pinger        = (*MySQL)(nil)
+)
 
 var (
 	// DefaultCharset default charset parameter for new databases.
@@ -27,73 +33,188 @@ var (
 // Accepts a single argument of "dsn", i.e:
 // username:password@tcp(localhost:3306)/myapp?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci
 func ConnectMySQL(dsn string) (*MySQL, error) {
-	conn, err := sql.Open("mysql", dsn)
+	return ConnectMySQLWith(dsn, ConnOptions{})
+}
+
+// ConnectMySQLWith same as `ConnectMySQL` but it accepts the connection pool,
+// timeout and retry settings, the connection is retried `ConnOptions.ConnectRetries` times.
+// It fails if the server may assign non consecutive ids to the rows of a batch insert,
+// see `Repository.ExecBatchInsert`.
+func ConnectMySQLWith(dsn string, opts ConnOptions) (*MySQL, error) {
+	db, err := OpenMySQLWith(dsn, opts)
 	if err != nil {
 		return nil, err
 	}
-	err = conn.Ping()
+	err = opts.ping(db.Conn)
+	if err == nil {
+		err = checkAutoIncrement(db.Conn)
+	}
 	if err != nil {
-		conn.Close()
+		db.Conn.Close()
 		return nil, err
 	}
 
+	return db, nil
+}
+
+// checkAutoIncrement returns an error if the server may assign non consecutive ids
+// to the rows of a single multi-row INSERT, which `Repository.ExecBatchInsert` relies on:
+// an auto_increment_increment other than 1, e.g. on Galera or multi-primary setups,
+// or the interleaved innodb_autoinc_lock_mode (2), the default of MySQL 8.
+func checkAutoIncrement(conn *sql.DB) error {
+	var increment, lockMode int
+	err := conn.QueryRow("SELECT @@auto_increment_increment, @@innodb_autoinc_lock_mode;").Scan(&increment, &lockMode)
+	if err != nil {
+		return err
+	}
+
+	if increment != 1 {
+		return fmt.Errorf("sql: auto_increment_increment is %d, batch inserts require 1", increment)
+	}
+	if lockMode == 2 {
+		return fmt.Errorf("sql: innodb_autoinc_lock_mode is 2 (interleaved), batch inserts require 0 or 1")
+	}
+
+	return nil
+}
+
+// OpenMySQL same as `ConnectMySQL` but it does not check the connection,
+// e.g. for the read replicas of a `Replicated` database which may be down on start.
+func OpenMySQL(dsn string) (*MySQL, error) {
+	return OpenMySQLWith(dsn, ConnOptions{})
+}
+
+// OpenMySQLWith same as `OpenMySQL` but it accepts the connection pool, timeout and retry settings.
+func OpenMySQLWith(dsn string, opts ConnOptions) (*MySQL, error) {
+	conn, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	opts.apply(conn)
+
 	return &MySQL{
 		Conn: conn,
+		opts: opts,
 	}, nil
 }
 
+// Ping implements the `Pinger` interface.
+func (db *MySQL) Ping(ctx context.Context) error {
+	return db.Conn.PingContext(ctx)
+}
+
+// Close closes the underline connection.
+func (db *MySQL) Close() error {
+	return db.Conn.Close()
+}
+
+// Dialect implements the `Dialecter` interface.
+func (db *MySQL) Dialect() Dialect {
+	return MySQLDialect
+}
+
 // CreateDatabase executes the CREATE DATABASE query.
 func (db *MySQL) CreateDatabase(database string) error {
-	q := fmt.Sprintf("CREATE DATABASE %s DEFAULT CHARSET = %s COLLATE = %s;", database, DefaultCharset, DefaultCollation)
+	q := fmt.Sprintf("CREATE DATABASE `%s` DEFAULT CHARSET = %s COLLATE = %s;", database, DefaultCharset, DefaultCollation)
 	_, err := db.Conn.Exec(q)
 	return err
 }
 
+// HasDatabase reports whether the "database" exists on the server.
+func (db *MySQL) HasDatabase(database string) (bool, error) {
+	var n int
+	err := db.Conn.QueryRow("SELECT COUNT(*) FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?;", database).Scan(&n)
+	return n > 0, err
+}
+
 // Drop executes the DROP DATABASE query.
 func (db *MySQL) Drop(database string) error {
-	q := fmt.Sprintf("DROP DATABASE %s;", database)
+	q := fmt.Sprintf("DROP DATABASE `%s`;", database)
 	_, err := db.Conn.Exec(q)
 	return err
 }
 
 // Select performs the SELECT query for this database (dsn database name is required).
+// It is retried on transient errors, see `ConnOptions.ReadRetries`.
 func (db *MySQL) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	rows, err := db.Conn.QueryContext(ctx, query, args...)
+	ctx, cancel := withTimeout(ctx, db.opts.QueryTimeout)
+	defer cancel()
+
+	return retryRead(ctx, db.opts.ReadRetries, func() error {
+		return selectContext(ctx, db.Conn, dest, query, args...)
+	})
+}
+
+// Get same as `Select` but it moves the cursor to the first result.
+func (db *MySQL) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withTimeout(ctx, db.opts.QueryTimeout)
+	defer cancel()
+
+	return retryRead(ctx, db.opts.ReadRetries, func() error {
+		return getContext(ctx, db.Conn, dest, query, args...)
+	})
+}
+
+// Exec executes a query. It does not return any rows.
+// Use the first output parameter to count the affected rows on UPDATE, INSERT, or DELETE.
+func (db *MySQL) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, db.opts.QueryTimeout)
+	defer cancel()
+
+	return db.Conn.ExecContext(ctx, query, args...)
+}
+
+// BeginTx starts a new transaction on the underline connection.
+// The caller is responsible to `Commit` or `Rollback` the result,
+// prefer `WithTx` which takes care of that.
+func (db *MySQL) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	conn, err := db.Conn.BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer rows.Close()
 
-	if scannable, ok := dest.(Scannable); ok {
-		return scannable.Scan(rows)
+	return &Tx{Conn: conn, dialect: MySQLDialect, timeout: db.opts.QueryTimeout}, nil
+}
+
+// WithTx runs "fn" inside a new transaction.
+// The transaction is committed when "fn" returns a nil error,
+// otherwise (error or panic) it is rolled back.
+func (db *MySQL) WithTx(ctx context.Context, fn func(tx Database) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
 	}
 
-	if !rows.Next() {
-		return ErrNoRows
+	return tx.run(fn)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func selectContext(ctx context.Context, conn queryer, dest interface{}, query string, args ...interface{}) error {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
 	}
-	return rows.Scan(dest)
+	defer rows.Close()
+
+	return scanAll(rows, dest)
 }
 
-// Get same as `Select` but it moves the cursor to the first result.
-func (db *MySQL) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
-	rows, err := db.Conn.QueryContext(ctx, query, args...)
+func getContext(ctx context.Context, conn queryer, dest interface{}, query string, args ...interface{}) error {
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			return err
+		}
 		return ErrNoRows
 	}
 
-	if scannable, ok := dest.(Scannable); ok {
-		return scannable.Scan(rows)
-	}
-
-	return rows.Scan(dest)
-}
-
-// Exec executes a query. It does not return any rows.
-// Use the first output parameter to count the affected rows on UPDATE, INSERT, or DELETE.
-func (db *MySQL) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	return db.Conn.ExecContext(ctx, query, args...)
+	return scanOne(rows, dest)
 }
@@ -0,0 +1,83 @@
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// autoIncDriver is a `driver.Driver` whose connections report the
+// "auto_increment_increment,innodb_autoinc_lock_mode" of their data source name.
+type autoIncDriver struct{}
+
+func (autoIncDriver) Open(name string) (driver.Conn, error) {
+	return autoIncConn(strings.Split(name, ",")), nil
+}
+
+type autoIncConn []string
+
+func (c autoIncConn) Prepare(string) (driver.Stmt, error) { return c, nil }
+func (c autoIncConn) Close() error                        { return nil }
+func (c autoIncConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+func (c autoIncConn) NumInput() int                       { return 0 }
+func (c autoIncConn) Exec([]driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (c autoIncConn) Query([]driver.Value) (driver.Rows, error) {
+	return &autoIncRows{values: c}, nil
+}
+
+type autoIncRows struct {
+	values []string
+	done   bool
+}
+
+func (r *autoIncRows) Columns() []string { return []string{"increment", "lock_mode"} }
+func (r *autoIncRows) Close() error      { return nil }
+func (r *autoIncRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+
+	for i, v := range r.values {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		dest[i] = n
+	}
+
+	return nil
+}
+
+func init() {
+	sql.Register("autoinctest", autoIncDriver{})
+}
+
+func TestCheckAutoIncrement(t *testing.T) {
+	tests := []struct {
+		settings string
+		ok       bool
+	}{
+		{"1,0", true},
+		{"1,1", true},
+		{"1,2", false},
+		{"2,1", false},
+	}
+
+	for _, tt := range tests {
+		conn, err := sql.Open("autoinctest", tt.settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = checkAutoIncrement(conn); (err == nil) != tt.ok {
+			t.Fatalf("%s: expected ok to be %t but got error %v", tt.settings, tt.ok, err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,201 @@
+package sql
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// Nullable is implemented by the column types which can hold a NULL value,
+// e.g. `NullString`. Pointer fields are nullable too, see `IsNullable`.
+type Nullable interface {
+	IsNull() bool
+}
+
+// IsNullable reports whether the "column" of the "rec" record can be set to NULL,
+// that is when its field is a pointer or a `Nullable` type.
+func IsNullable(rec Record, column string) bool {
+	typ := indirectType(reflect.TypeOf(rec))
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+
+	index, ok := FieldsOf(typ)[column]
+	if !ok {
+		return false
+	}
+
+	fieldType := typ.FieldByIndex(index).Type
+	return fieldType.Kind() == reflect.Ptr || fieldType.Implements(nullableType)
+}
+
+var nullableType = reflect.TypeOf((*Nullable)(nil)).Elem()
+
+var jsonNull = []byte("null")
+
+// NullString is a string column which may be NULL.
+// It is encoded as a JSON string or null.
+type NullString struct {
+	sql.NullString
+}
+
+// NewNullString returns a valid NullString of "s".
+func NewNullString(s string) NullString {
+	return NullString{sql.NullString{String: s, Valid: true}}
+}
+
+// IsNull implements the `Nullable` interface.
+func (n NullString) IsNull() bool { return !n.Valid }
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (n NullString) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.String)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (n *NullString) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*n = NullString{}
+		return nil
+	}
+
+	n.Valid = true
+	return json.Unmarshal(b, &n.String)
+}
+
+// NullInt64 is an integer column which may be NULL.
+// It is encoded as a JSON number or null.
+type NullInt64 struct {
+	sql.NullInt64
+}
+
+// NewNullInt64 returns a valid NullInt64 of "i".
+func NewNullInt64(i int64) NullInt64 {
+	return NullInt64{sql.NullInt64{Int64: i, Valid: true}}
+}
+
+// IsNull implements the `Nullable` interface.
+func (n NullInt64) IsNull() bool { return !n.Valid }
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Int64)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (n *NullInt64) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*n = NullInt64{}
+		return nil
+	}
+
+	n.Valid = true
+	return json.Unmarshal(b, &n.Int64)
+}
+
+// NullFloat64 is a floating point column which may be NULL.
+// It is encoded as a JSON number or null.
+type NullFloat64 struct {
+	sql.NullFloat64
+}
+
+// NewNullFloat64 returns a valid NullFloat64 of "f".
+func NewNullFloat64(f float64) NullFloat64 {
+	return NullFloat64{sql.NullFloat64{Float64: f, Valid: true}}
+}
+
+// IsNull implements the `Nullable` interface.
+func (n NullFloat64) IsNull() bool { return !n.Valid }
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (n NullFloat64) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Float64)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (n *NullFloat64) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*n = NullFloat64{}
+		return nil
+	}
+
+	n.Valid = true
+	return json.Unmarshal(b, &n.Float64)
+}
+
+// NullBool is a boolean column which may be NULL.
+// It is encoded as a JSON boolean or null.
+type NullBool struct {
+	sql.NullBool
+}
+
+// NewNullBool returns a valid NullBool of "b".
+func NewNullBool(b bool) NullBool {
+	return NullBool{sql.NullBool{Bool: b, Valid: true}}
+}
+
+// IsNull implements the `Nullable` interface.
+func (n NullBool) IsNull() bool { return !n.Valid }
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (n NullBool) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Bool)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (n *NullBool) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*n = NullBool{}
+		return nil
+	}
+
+	n.Valid = true
+	return json.Unmarshal(b, &n.Bool)
+}
+
+// NullTime is a time column which may be NULL.
+// It is encoded as a JSON RFC 3339 string or null.
+type NullTime struct {
+	sql.NullTime
+}
+
+// NewNullTime returns a valid NullTime of "t".
+func NewNullTime(t time.Time) NullTime {
+	return NullTime{sql.NullTime{Time: t, Valid: true}}
+}
+
+// IsNull implements the `Nullable` interface.
+func (n NullTime) IsNull() bool { return !n.Valid }
+
+// MarshalJSON implements the `json.Marshaler` interface.
+func (n NullTime) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return jsonNull, nil
+	}
+	return json.Marshal(n.Time)
+}
+
+// UnmarshalJSON implements the `json.Unmarshaler` interface.
+func (n *NullTime) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, jsonNull) {
+		*n = NullTime{}
+		return nil
+	}
+
+	n.Valid = true
+	return json.Unmarshal(b, &n.Time)
+}
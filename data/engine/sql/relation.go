@@ -0,0 +1,281 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Relation describes the records of another table which belong to a record, see `Related`.
+type Relation struct {
+	// Name is the name of the relation on `Repository.Preload`, e.g. "category".
+	Name string
+	// Of is a pointer to a related record, e.g. new(Category).
+	Of Record
+	// ForeignKey is the column which links the records: a column of the record itself
+	// on a `BelongsTo` relation, e.g. "category_id", or of the related records on a `HasMany` one,
+	// e.g. "dest_id". It holds the primary key of the other side.
+	ForeignKey string
+	// Field is the name of the struct field which holds the related records, usually tagged as db:"-".
+	// It is a pointer to the related record on a `BelongsTo` relation,
+	// and a slice of pointers to the related records on a `HasMany` one.
+	Field string
+	// Many reports whether it is a `HasMany` relation.
+	Many bool
+}
+
+// BelongsTo returns a relation to the single "of" record whose primary key is
+// the value of the "foreignKey" column of the record, e.g. a destination's category.
+func BelongsTo(name string, of Record, foreignKey, field string) Relation {
+	return Relation{Name: name, Of: of, ForeignKey: foreignKey, Field: field}
+}
+
+// HasMany returns a relation to the "of" records whose "foreignKey" column
+// holds the primary key of the record, e.g. the ratings of a destination.
+func HasMany(name string, of Record, foreignKey, field string) Relation {
+	return Relation{Name: name, Of: of, ForeignKey: foreignKey, Field: field, Many: true}
+}
+
+// Related can be optionally implemented by a Record to declare its relations,
+// so they can be fetched along with the records, see `Repository.Preload`.
+type Related interface {
+	Relations() []Relation
+}
+
+// RelationOf returns the relation of the "rec" record by its "name".
+func RelationOf(rec Record, name string) (Relation, bool) {
+	if r, ok := rec.(Related); ok {
+		for _, rel := range r.Relations() {
+			if rel.Name == name {
+				return rel, true
+			}
+		}
+	}
+
+	return Relation{}, false
+}
+
+// ValidatePreload returns `ErrUnprocessable` if any of the relation "names"
+// is not a relation of the "rec" record. Nested relations are separated by a dot,
+// e.g. "ratings.user" are the users of the ratings.
+func ValidatePreload(rec Record, names ...string) error {
+	for _, group := range groupPreload(names) {
+		rel, ok := RelationOf(rec, group.name)
+		if !ok || !rel.valid(rec) {
+			return ErrUnprocessable
+		}
+
+		if err := ValidatePreload(rel.Of, group.nested...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// valid reports whether the foreign key and the field of the relation match the "rec" record.
+func (rel Relation) valid(rec Record) bool {
+	typ := indirectType(reflect.TypeOf(rec))
+	field, ok := typ.FieldByName(rel.Field)
+	if !ok {
+		return false
+	}
+
+	related := indirectType(reflect.TypeOf(rel.Of))
+	if rel.Many {
+		return field.Type.Kind() == reflect.Slice && indirectType(field.Type.Elem()) == related &&
+			HasColumn(rel.Of, rel.ForeignKey)
+	}
+
+	return indirectType(field.Type) == related && HasColumn(rec, rel.ForeignKey)
+}
+
+// LoadFunc binds the "of" records whose "column" value is one of the "values"
+// to "dest", a pointer to a slice of pointers to records. See `PreloadWith`.
+type LoadFunc func(ctx context.Context, of Record, column string, values []interface{}, dest interface{}) error
+
+// PreloadWith fetches the "names" relations of the "rec" records of "dest",
+// a pointer to a record or to a slice of records, through the "load" function,
+// one call for each relation, and sets them to the relations' fields.
+// It returns `ErrUnprocessable` if any of the names is not a relation of the record.
+func PreloadWith(ctx context.Context, rec Record, dest interface{}, load LoadFunc, names ...string) error {
+	if err := ValidatePreload(rec, names...); err != nil {
+		return err
+	}
+
+	records := recordsOf(dest)
+	if len(records) == 0 {
+		return nil
+	}
+
+	for _, group := range groupPreload(names) {
+		rel, _ := RelationOf(rec, group.name)
+		if err := rel.preload(ctx, rec, records, load, group.nested); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rel Relation) preload(ctx context.Context, rec Record, records []reflect.Value, load LoadFunc, nested []string) error {
+	// the column of the records and the column of the related records which hold the same value.
+	column, relatedColumn := rel.ForeignKey, rel.Of.PrimaryKey()
+	if rel.Many {
+		column, relatedColumn = rec.PrimaryKey(), rel.ForeignKey
+	}
+
+	var (
+		values []interface{}
+		seen   = make(map[string]bool)
+	)
+	for _, v := range records {
+		value := plainValue(v.FieldByIndex(FieldsOf(v.Type())[column]).Interface())
+		if value == nil || reflect.ValueOf(value).IsZero() {
+			continue
+		}
+
+		if key := valueKey(value); !seen[key] {
+			seen[key] = true
+			values = append(values, value)
+		}
+	}
+
+	related := reflect.New(reflect.SliceOf(reflect.PtrTo(indirectType(reflect.TypeOf(rel.Of)))))
+	for start := 0; start < len(values); start += preloadChunkSize {
+		end := start + preloadChunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+
+		chunk := reflect.New(related.Elem().Type())
+		if err := load(ctx, rel.Of, relatedColumn, values[start:end], chunk.Interface()); err != nil && err != ErrNoRows {
+			return err
+		}
+		related.Elem().Set(reflect.AppendSlice(related.Elem(), chunk.Elem()))
+	}
+
+	if len(nested) > 0 && related.Elem().Len() > 0 {
+		if err := PreloadWith(ctx, rel.Of, related.Interface(), load, nested...); err != nil {
+			return err
+		}
+	}
+
+	byKey := make(map[string][]reflect.Value)
+	for i := 0; i < related.Elem().Len(); i++ {
+		ptr := related.Elem().Index(i)
+		key := valueKey(plainValue(ptr.Elem().FieldByIndex(FieldsOf(ptr.Elem().Type())[relatedColumn]).Interface()))
+		byKey[key] = append(byKey[key], ptr)
+	}
+
+	for _, v := range records {
+		key := valueKey(plainValue(v.FieldByIndex(FieldsOf(v.Type())[column]).Interface()))
+		setRelated(v.FieldByName(rel.Field), byKey[key], rel.Many)
+	}
+
+	return nil
+}
+
+// preloadChunkSize is the maximum number of values of a single relation query.
+const preloadChunkSize = 1000
+
+// setRelated sets the "field" of a relation to the "related" records, pointers to structs.
+func setRelated(field reflect.Value, related []reflect.Value, many bool) {
+	if !many {
+		field.Set(reflect.Zero(field.Type()))
+		if len(related) == 0 {
+			return
+		}
+
+		if field.Kind() == reflect.Ptr {
+			field.Set(related[0])
+		} else {
+			field.Set(related[0].Elem())
+		}
+		return
+	}
+
+	list := reflect.MakeSlice(field.Type(), 0, len(related))
+	for _, ptr := range related {
+		if field.Type().Elem().Kind() == reflect.Ptr {
+			list = reflect.Append(list, ptr)
+		} else {
+			list = reflect.Append(list, ptr.Elem())
+		}
+	}
+	field.Set(list)
+}
+
+// recordsOf returns the addressable struct values of "dest",
+// a pointer to a struct or to a slice of structs or of pointers to structs.
+func recordsOf(dest interface{}) []reflect.Value {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return nil
+	}
+	v = v.Elem()
+
+	switch {
+	case v.Kind() == reflect.Struct:
+		return []reflect.Value{v}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Ptr:
+		records := make([]reflect.Value, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if !v.Index(i).IsNil() {
+				records = append(records, v.Index(i).Elem())
+			}
+		}
+		return records
+	case v.Kind() == reflect.Slice:
+		records := make([]reflect.Value, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			records = append(records, v.Index(i))
+		}
+		return records
+	default:
+		return nil
+	}
+}
+
+type preloadGroup struct {
+	name   string
+	nested []string
+}
+
+// groupPreload groups the relation "names" by their first part,
+// e.g. "ratings", "ratings.user" and "category" result to ratings (user) and category.
+func groupPreload(names []string) []preloadGroup {
+	var groups []preloadGroup
+	index := make(map[string]int)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		nested := ""
+		if dot := strings.IndexByte(name, '.'); dot >= 0 {
+			name, nested = name[:dot], name[dot+1:]
+		}
+
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, preloadGroup{name: name})
+		}
+
+		if nested != "" {
+			groups[i].nested = append(groups[i].nested, nested)
+		}
+	}
+
+	return groups
+}
+
+// valueKey returns the text of a column value, equal values of different integer types,
+// e.g. an int64 key and a `NullInt64` foreign key, result to the same text.
+func valueKey(v interface{}) string {
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,284 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Pinger is implemented by the databases which can report
+// whether their server is reachable, e.g. `MySQL` and `Postgres`.
+// It is used by the health checks of the `Replicated` database.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ReplicaOptions holds the health check settings of a `Replicated` database.
+type ReplicaOptions struct {
+	// CheckInterval is the time between two health checks of the replicas.
+	// Defaults to 5 seconds.
+	CheckInterval time.Duration
+	// CheckTimeout is the time a replica has to answer a health check.
+	// Defaults to 2 seconds.
+	CheckTimeout time.Duration
+}
+
+// Replicated is a `Database` which sends the `Exec` queries and the transactions
+// to a primary database and spreads the `Select` and `Get` queries
+// over a pool of read replicas, in round robin.
+//
+// Replicas which fail a health check, or a read with a connection error,
+// are skipped until a later health check succeeds; when no replica is healthy
+// the reads go to the primary. Reads on a context created by `WithPrimary`
+// always go to the primary, e.g. to read the records a request has just written.
+// See the `NewReplicated` package-level function.
+type Replicated struct {
+	primary  Database
+	replicas []*replica
+	next     uint32 // the round robin counter.
+	opts     ReplicaOptions
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+var (
+	_ Database      = (*Replicated)(nil)
+	_ Transactional = (*Replicated)(nil)
+	_ Dialecter     = (*Replicated)(nil)
+)
+
+type replica struct {
+	db      Database
+	healthy int32 // 1 or 0, accessed atomically.
+}
+
+func (r *replica) isHealthy() bool {
+	return atomic.LoadInt32(&r.healthy) == 1
+}
+
+func (r *replica) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt32(&r.healthy, v)
+}
+
+// NewReplicated returns a new `Replicated` database of the "primary" and its read "replicas".
+// It checks the health of the replicas once and then every `ReplicaOptions.CheckInterval`,
+// until `Close` is called.
+func NewReplicated(primary Database, replicas []Database, opts ReplicaOptions) *Replicated {
+	if opts.CheckInterval <= 0 {
+		opts.CheckInterval = 5 * time.Second
+	}
+	if opts.CheckTimeout <= 0 {
+		opts.CheckTimeout = 2 * time.Second
+	}
+
+	db := &Replicated{
+		primary: primary,
+		opts:    opts,
+		stop:    make(chan struct{}),
+	}
+	for _, r := range replicas {
+		db.replicas = append(db.replicas, &replica{db: r})
+	}
+
+	db.check()
+	go db.checkLoop()
+
+	return db
+}
+
+// Primary returns the primary database.
+func (db *Replicated) Primary() Database {
+	return db.primary
+}
+
+// Healthy returns the number of the replicas which serve the reads.
+func (db *Replicated) Healthy() int {
+	n := 0
+	for _, r := range db.replicas {
+		if r.isHealthy() {
+			n++
+		}
+	}
+
+	return n
+}
+
+func (db *Replicated) checkLoop() {
+	ticker := time.NewTicker(db.opts.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.stop:
+			return
+		case <-ticker.C:
+			db.check()
+		}
+	}
+}
+
+// check pings all the replicas concurrently and updates their health.
+// Replicas which do not implement the `Pinger` interface are always healthy.
+func (db *Replicated) check() {
+	var wg sync.WaitGroup
+	for _, r := range db.replicas {
+		p, ok := r.db.(Pinger)
+		if !ok {
+			r.setHealthy(true)
+			continue
+		}
+
+		wg.Add(1)
+		go func(r *replica, p Pinger) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), db.opts.CheckTimeout)
+			defer cancel()
+			r.setHealthy(p.Ping(ctx) == nil)
+		}(r, p)
+	}
+	wg.Wait()
+}
+
+// read runs "fn" on the next healthy replica, if a replica fails with a connection error
+// it is marked as unhealthy and the next one is tried. The primary is used
+// when the context is created by `WithPrimary` or no replica is available.
+func (db *Replicated) read(ctx context.Context, fn func(Database) error) error {
+	if !UsesPrimary(ctx) {
+		n := uint32(len(db.replicas))
+		// the counter wraps around, the index is computed
+		// unsigned so it never gets negative on 32-bit platforms.
+		start := atomic.AddUint32(&db.next, 1)
+		for i := uint32(0); i < n; i++ {
+			r := db.replicas[(start+i)%n]
+			if !r.isHealthy() {
+				continue
+			}
+
+			err := fn(r.db)
+			if !isConnError(err) {
+				return err
+			}
+			r.setHealthy(false)
+		}
+	}
+
+	return fn(db.primary)
+}
+
+// Select performs the SELECT query on a replica.
+func (db *Replicated) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.read(ctx, func(r Database) error {
+		return r.Select(ctx, dest, query, args...)
+	})
+}
+
+// Get same as `Select` but it moves the cursor to the first result.
+func (db *Replicated) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return db.read(ctx, func(r Database) error {
+		return r.Get(ctx, dest, query, args...)
+	})
+}
+
+// Exec executes a query on the primary.
+func (db *Replicated) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.primary.Exec(ctx, query, args...)
+}
+
+// WithTx runs "fn" inside a new transaction of the primary,
+// all the queries of the transaction, reads included, run on the primary.
+// Returns `ErrTxUnsupported` if the primary does not support transactions.
+func (db *Replicated) WithTx(ctx context.Context, fn func(tx Database) error) error {
+	t, ok := db.primary.(Transactional)
+	if !ok {
+		return ErrTxUnsupported
+	}
+
+	return t.WithTx(ctx, fn)
+}
+
+// Dialect implements the `Dialecter` interface, it returns the dialect of the primary.
+func (db *Replicated) Dialect() Dialect {
+	return DialectOf(db.primary)
+}
+
+// Ping implements the `Pinger` interface, it pings the primary.
+func (db *Replicated) Ping(ctx context.Context) error {
+	if p, ok := db.primary.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+
+	return nil
+}
+
+// Close stops the health checks and closes the primary and the replicas.
+func (db *Replicated) Close() error {
+	var err error
+	db.closeOnce.Do(func() {
+		close(db.stop)
+
+		for _, r := range db.replicas {
+			if c, ok := r.db.(interface{ Close() error }); ok {
+				c.Close()
+			}
+		}
+
+		if c, ok := db.primary.(interface{ Close() error }); ok {
+			err = c.Close()
+		}
+	})
+
+	return err
+}
+
+// isConnError reports whether "err" is caused by the connection to the database server,
+// rather than the query itself, so the query can be retried on another server.
+// A canceled or timed out context is not, even though `context.DeadlineExceeded` is a `net.Error`:
+// the server is healthy and retrying the slow query elsewhere would only add load.
+func isConnError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+type primaryKey struct{}
+
+// WithPrimary returns a copy of "ctx" which makes a `Replicated` database
+// read from the primary, e.g. to read the records written by the same request
+// before they reach the replicas.
+func WithPrimary(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether "ctx" is created by `WithPrimary`.
+func UsesPrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	v, _ := ctx.Value(primaryKey{}).(bool)
+	return v
+}
@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// namedDB is a `Database` which reports its name on each read.
+type namedDB struct {
+	name string
+	err  error
+}
+
+func (db namedDB) Get(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	*dest.(*string) = db.name
+	return db.err
+}
+
+func (db namedDB) Select(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	return db.Get(ctx, dest, q, args...)
+}
+
+func (db namedDB) Exec(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	return nil, nil
+}
+
+func readFrom(t *testing.T, db *Replicated, ctx context.Context) string {
+	t.Helper()
+
+	var name string
+	if err := db.Get(ctx, &name, "SELECT 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	return name
+}
+
+func TestReplicatedRoundRobin(t *testing.T) {
+	tests := []struct {
+		name     string
+		next     uint32
+		expected []string
+	}{
+		{"start", 0, []string{"r2", "r3", "r1", "r2"}},
+		{"wrap around", math.MaxUint32 - 1, []string{"r1", "r1", "r2", "r3"}},
+		{"past int32", math.MaxInt32, []string{"r3", "r1", "r2", "r3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewReplicated(namedDB{name: "primary"},
+				[]Database{namedDB{name: "r1"}, namedDB{name: "r2"}, namedDB{name: "r3"}}, ReplicaOptions{})
+			defer db.Close()
+			db.next = tt.next
+
+			for i, expected := range tt.expected {
+				if got := readFrom(t, db, context.Background()); got != expected {
+					t.Fatalf("[%d] expected read from %s but got %s", i, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReplicatedContextError(t *testing.T) {
+	for _, err := range []error{context.DeadlineExceeded, context.Canceled, fmt.Errorf("query: %w", context.DeadlineExceeded)} {
+		t.Run(err.Error(), func(t *testing.T) {
+			db := NewReplicated(namedDB{name: "primary"},
+				[]Database{namedDB{name: "r1", err: err}, namedDB{name: "r2"}}, ReplicaOptions{})
+			defer db.Close()
+			db.next = 1 // read from r1.
+
+			var name string
+			if got := db.Get(context.Background(), &name, "SELECT 1"); !errors.Is(got, err) {
+				t.Fatalf("expected error %v but got %v", err, got)
+			}
+			if name != "r1" {
+				t.Fatalf("expected the query not to be retried on another server but it was read from %s", name)
+			}
+			if healthy := db.Healthy(); healthy != 2 {
+				t.Fatalf("expected 2 healthy replicas but got %d", healthy)
+			}
+		})
+	}
+}
+
+func TestReplicatedPrimary(t *testing.T) {
+	db := NewReplicated(namedDB{name: "primary"},
+		[]Database{namedDB{name: "r1", err: driver.ErrBadConn}, namedDB{name: "r2"}}, ReplicaOptions{})
+	defer db.Close()
+
+	if got := readFrom(t, db, WithPrimary(context.Background())); got != "primary" {
+		t.Fatalf("expected WithPrimary to read from the primary but got %s", got)
+	}
+
+	// r1 fails with a connection error, it is skipped from now on.
+	for i := 0; i < 3; i++ {
+		if got := readFrom(t, db, context.Background()); got != "r2" {
+			t.Fatalf("[%d] expected read from r2 but got %s", i, got)
+		}
+	}
+	if healthy := db.Healthy(); healthy != 1 {
+		t.Fatalf("expected 1 healthy replica but got %d", healthy)
+	}
+}
@@ -3,12 +3,15 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Repository holder for common queries.
@@ -16,6 +19,9 @@ import (
 type Repository struct {
 	db  Database
 	rec Record // see `Count`, `List` and `DeleteByID` methods.
+
+	unscoped bool     // see `Unscoped`.
+	preload  []string // see `Preload`.
 }
 
 // NewRepository returns a new (SQL) base service for common operations.
@@ -28,6 +34,71 @@ func (r *Repository) DB() Database {
 	return r.db
 }
 
+// Dialect returns the dialect of the database, see `DialectOf`.
+func (r *Repository) Dialect() Dialect {
+	return DialectOf(r.db)
+}
+
+// Join returns a copy of the Repository which runs its queries on "tx",
+// e.g. the `Database` passed on a `WithTx` function.
+func (r *Repository) Join(tx Database) *Repository {
+	return &Repository{db: tx, rec: r.rec, unscoped: r.unscoped, preload: r.preload}
+}
+
+// Unscoped returns a copy of the Repository which includes
+// the soft deleted records on its queries, see `SoftDeletable`.
+func (r *Repository) Unscoped() *Repository {
+	return &Repository{db: r.db, rec: r.rec, unscoped: true, preload: r.preload}
+}
+
+// Preload returns a copy of the Repository which fetches the "names" relations
+// of the records it reads, see `Related`. The relations are fetched
+// with one query for each relation, e.g. `Preload("category", "ratings.user")`.
+// The `GetByID`, `GetByAttrs`, `GetAll`, `List` and `ListPage` methods
+// return `ErrUnprocessable` if any of the names is not a relation of the record.
+func (r *Repository) Preload(names ...string) *Repository {
+	preload := append(append([]string{}, r.preload...), names...)
+	return &Repository{db: r.db, rec: r.rec, unscoped: r.unscoped, preload: preload}
+}
+
+// preloadInto fetches the "names" relations of the records of "dest", see `Preload`.
+func (r *Repository) preloadInto(ctx context.Context, dest interface{}, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	return PreloadWith(ctx, r.rec, dest, r.loadRelated, names...)
+}
+
+// loadRelated is the `LoadFunc` of the Repository, the soft deleted
+// related records are excluded unless the Repository is `Unscoped`.
+func (r *Repository) loadRelated(ctx context.Context, of Record, column string, values []interface{}, dest interface{}) error {
+	related := &Repository{db: r.db, rec: of, unscoped: r.unscoped}
+	return related.List(ctx, dest, ListOptions{Filter: In(column, values...)})
+}
+
+// softDeleteScope returns the soft delete column of the record
+// if its deleted records should be hidden from the queries, otherwise empty.
+func (r *Repository) softDeleteScope(ctx context.Context) string {
+	if r.unscoped || IncludesDeleted(ctx) {
+		return ""
+	}
+
+	return SoftDeleteColumnOf(r.rec)
+}
+
+// WithTx runs "fn" inside a transaction of the underline database.
+// If the Repository already joined a transaction then "fn" runs on that one.
+// Returns `ErrTxUnsupported` if the database does not support transactions.
+func (r *Repository) WithTx(ctx context.Context, fn func(tx Database) error) error {
+	t, ok := r.db.(Transactional)
+	if !ok {
+		return ErrTxUnsupported
+	}
+
+	return t.WithTx(ctx, fn)
+}
+
 // RecordInfo returns the record info provided through `NewService`.
 func (r *Repository) RecordInfo() Record {
 	return r.rec
@@ -40,6 +111,9 @@ var ErrNoRows = sql.ErrNoRows
 // Count returns the total records count in the table.
 func (r *Repository) Count(ctx context.Context) (total int64, err error) {
 	q := fmt.Sprintf("SELECT COUNT(DISTINCT %s) FROM %s", r.rec.PrimaryKey(), r.rec.TableName())
+	if column := r.softDeleteScope(ctx); column != "" {
+		q += fmt.Sprintf(" WHERE %s IS NULL", column)
+	}
 	if err = r.db.Select(ctx, &total, q); err == sql.ErrNoRows {
 		err = nil
 	}
@@ -48,11 +122,25 @@ func (r *Repository) Count(ctx context.Context) (total int64, err error) {
 
 // GetByID binds a single record from the databases to the "dest".
 func (r *Repository) GetByID(ctx context.Context, dest interface{}, id int64) error {
-	q := fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", r.rec.TableName(), r.rec.PrimaryKey())
-	err := r.db.Get(ctx, dest, q, id)
-	return err
+	where := fmt.Sprintf("%s = ?", r.rec.PrimaryKey())
+	if column := r.softDeleteScope(ctx); column != "" {
+		where += fmt.Sprintf(" AND %s IS NULL", column)
+	}
+
+	if err := ValidatePreload(r.rec, r.preload...); err != nil {
+		return err
+	}
+
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT 1", r.rec.TableName(), where)
+	if err := r.db.Get(ctx, dest, r.Dialect().Rebind(q), id); err != nil {
+		return err
+	}
+
+	return r.preloadInto(ctx, dest, r.preload)
 }
 
+// GetByAttrs binds the first record that matches all the "attrs" column-value pairs to the "dest".
+// Returns `ErrUnprocessable` if any of the keys is not a column of the record.
 func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error {
 	if len(attrs) == 0 {
 		return nil
@@ -63,36 +151,77 @@ func (r *Repository) GetByAttrs(ctx context.Context, dest interface{}, attrs map
 		values   []interface{}
 	)
 
-	for k, v := range attrs {
+	for _, k := range sortedKeys(attrs) {
+		if !HasColumn(r.rec, k) {
+			return ErrUnprocessable
+		}
+
 		keyLines = append(keyLines, fmt.Sprintf("%s = ?", k))
-		values = append(values, v)
+		values = append(values, attrs[k])
 	}
 
-	if len(values) == 0 {
-		return nil
+	if column := r.softDeleteScope(ctx); column != "" {
+		keyLines = append(keyLines, fmt.Sprintf("%s IS NULL", column))
 	}
 
-	q := fmt.Sprintf("SELECT * FROM %s WHERE %s;",
-		r.rec.TableName(), strings.Join(keyLines, ", "))
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s LIMIT 1;",
+		r.rec.TableName(), strings.Join(keyLines, " AND "))
+
+	if err := ValidatePreload(r.rec, r.preload...); err != nil {
+		return err
+	}
 
-	err := r.db.Get(ctx, dest, q, values...)
+	err := r.db.Get(ctx, dest, r.Dialect().Rebind(q), values...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return r.preloadInto(ctx, dest, r.preload)
 }
 
+// GetAll binds all the records of the table to the "dest".
 func (r *Repository) GetAll(ctx context.Context, dest interface{}) error {
 	q := fmt.Sprintf("SELECT * FROM %s", r.rec.TableName())
-	err := r.db.Get(ctx, dest, q)
-	return err
+	if column := r.softDeleteScope(ctx); column != "" {
+		q += fmt.Sprintf(" WHERE %s IS NULL", column)
+	}
+
+	if err := ValidatePreload(r.rec, r.preload...); err != nil {
+		return err
+	}
+
+	if err := r.db.Select(ctx, dest, q); err != nil {
+		return err
+	}
+
+	return r.preloadInto(ctx, dest, r.preload)
 }
 
-// DeleteByID removes a single record of "dest" from the database.
+// DeleteByID removes a single record of "dest" from the database,
+// `SoftDeletable` records are marked as deleted instead, see `Purge`.
+// The `AfterDeleteHook` of the repository's record is called when a record was removed.
 func (r *Repository) DeleteByID(ctx context.Context, id int64) (int, error) {
-	q := fmt.Sprintf("DELETE FROM %s WHERE %s = ? LIMIT 1", r.rec.TableName(), r.rec.PrimaryKey())
-	res, err := r.db.Exec(ctx, q, id)
+	column := SoftDeleteColumnOf(r.rec)
+	if column == "" {
+		return r.Purge(ctx, id)
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ? AND %s IS NULL",
+		r.rec.TableName(), column, r.rec.PrimaryKey(), column)
+	return r.execDelete(ctx, id, q, time.Now(), id)
+}
+
+// Restore brings back the soft deleted "id" record, see `SoftDeletable`.
+// It returns the affected number (0 when the record is not deleted otherwise 1).
+func (r *Repository) Restore(ctx context.Context, id int64) (int, error) {
+	column := SoftDeleteColumnOf(r.rec)
+	if column == "" {
+		return 0, ErrUnprocessable
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s = ? AND %s IS NOT NULL",
+		r.rec.TableName(), column, r.rec.PrimaryKey(), column)
+	res, err := r.db.Exec(ctx, r.Dialect().Rebind(q), id)
 	if err != nil {
 		return 0, err
 	}
@@ -100,6 +229,31 @@ func (r *Repository) DeleteByID(ctx context.Context, id int64) (int, error) {
 	return GetAffectedRows(res), nil
 }
 
+// Purge removes a single record from the database, even if it is `SoftDeletable`.
+// The `AfterDeleteHook` of the repository's record is called when a record was removed.
+func (r *Repository) Purge(ctx context.Context, id int64) (int, error) {
+	d := r.Dialect()
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s = ?%s", r.rec.TableName(), r.rec.PrimaryKey(), d.DeleteLimit(1))
+	return r.execDelete(ctx, id, q, id)
+}
+
+// execDelete executes the "q" query which removes the "id" record and calls the `AfterDeleteHook`.
+func (r *Repository) execDelete(ctx context.Context, id int64, q string, args ...interface{}) (int, error) {
+	res, err := r.db.Exec(ctx, r.Dialect().Rebind(q), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n := GetAffectedRows(res)
+	if n > 0 {
+		if err = AfterDelete(ctx, r.rec, id); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
 // ListOptions holds the options to be passed on the `Service.List` method.
 type ListOptions struct {
 	Table         string // the table name.
@@ -109,6 +263,15 @@ type ListOptions struct {
 	Order         string // "ASC" or "DESC" (could be a bool type instead).
 	WhereColumn   string
 	WhereValue    interface{}
+	Filter        Filter  // joined with the `WhereColumn` condition using AND.
+	PrimaryKey    string  // the tiebreak sort column, set by `Repository.List`.
+	Cursor        string  // keyset pagination token, see `EncodeCursor`. Offset is ignored when set.
+	Dialect       Dialect // renders the placeholders, defaults to `MySQLDialect`. Set by `Repository.List`.
+	// Include are the relations to fetch along with the records, see `Repository.Preload`.
+	Include []string
+	// IncludeDeleted lists the soft deleted records too, see `SoftDeletable`.
+	// It is not parsed by `ParseListOptions`, callers should only set it for admins.
+	IncludeDeleted bool
 }
 
 // Where accepts a column name and column value to set
@@ -121,47 +284,156 @@ func (opt ListOptions) Where(colName string, colValue interface{}) ListOptions {
 	return opt
 }
 
+// Match accepts one or more filters to set on the WHERE clause
+// of the result query, they are joined with any existing filter using AND.
+// It returns a new `ListOptions` value.
+func (opt ListOptions) Match(filters ...Filter) ListOptions {
+	if opt.Filter != nil {
+		filters = append([]Filter{opt.Filter}, filters...)
+	}
+
+	opt.Filter = And(filters...)
+	return opt
+}
+
+// Preload accepts one or more relations to fetch along with the listed records,
+// see `Repository.Preload`. It returns a new `ListOptions` value.
+func (opt ListOptions) Preload(names ...string) ListOptions {
+	opt.Include = append(append([]string{}, opt.Include...), names...)
+	return opt
+}
+
 // BuildQuery returns the query and the arguments that
 // should be form a SELECT command.
 func (opt ListOptions) BuildQuery() (q string, args []interface{}) {
 	q = fmt.Sprintf("SELECT * FROM %s", opt.Table)
 
+	order := ParseOrder(opt.Order)
+	orderColumns := splitColumns(opt.OrderByColumn)
+
+	where := And(opt.Filter)
 	if opt.WhereColumn != "" && opt.WhereValue != nil {
-		q += fmt.Sprintf(" WHERE %s = ?", opt.WhereColumn)
-		args = append(args, opt.WhereValue)
+		where.Filters = append(where.Filters, Eq(opt.WhereColumn, opt.WhereValue))
+	}
+
+	if opt.Cursor != "" && opt.PrimaryKey != "" {
+		if c, err := DecodeCursor(opt.Cursor); err == nil {
+			k := keyset{PrimaryKey: opt.PrimaryKey, Desc: order == descending, Cursor: c}
+			if len(orderColumns) > 0 {
+				k.Column = orderColumns[0]
+			}
+			where.Filters = append(where.Filters, k)
+		}
+	}
+
+	if cond, condArgs := where.Build(); cond != "" {
+		q += " WHERE " + cond
+		args = append(args, condArgs...)
 	}
 
-	if opt.OrderByColumn != "" {
-		q += fmt.Sprintf(" ORDER BY %s %s", opt.OrderByColumn, ParseOrder(opt.Order))
+	// Rows with equal sort values keep a stable order across pages.
+	if opt.PrimaryKey != "" && !containsString(orderColumns, opt.PrimaryKey) {
+		orderColumns = append(orderColumns, opt.PrimaryKey)
+	}
+
+	if len(orderColumns) > 0 {
+		d := opt.Dialect
+		if d == nil {
+			d = MySQLDialect
+		}
+
+		// NULLs are the lowest values on every database, see `keyset`.
+		terms := make([]string, 0, len(orderColumns))
+		for _, column := range orderColumns {
+			term := column + " " + order
+			if column != opt.PrimaryKey {
+				term += d.OrderNulls(order == descending)
+			}
+			terms = append(terms, term)
+		}
+		q += " ORDER BY " + strings.Join(terms, ", ")
 	}
 
 	if opt.Limit > 0 {
 		q += fmt.Sprintf(" LIMIT %d", opt.Limit) // offset below.
 	}
 
-	if opt.Offset > 0 {
+	if opt.Offset > 0 && opt.Cursor == "" {
 		q += fmt.Sprintf(" OFFSET %d", opt.Offset)
 	}
 
+	if opt.Dialect != nil {
+		q = opt.Dialect.Rebind(q)
+	}
+
 	return
 }
 
 // const defaultLimit = 30 // default limit if not set.
 
 // ParseListOptions returns a `ListOptions` from a map[string][]string.
+// Filters are passed as column[op]=value, see `parseFilters`.
+// Pagination is done either by ?cursor= or by ?offset=, the cursor wins if both are present.
 func ParseListOptions(q url.Values) ListOptions {
 	offset, _ := strconv.ParseUint(q.Get("offset"), 10, 64)
 	limit, _ := strconv.ParseUint(q.Get("limit"), 10, 64)
-	order := q.Get("order") // empty, asc(...) or desc(...).
-	orderBy := q.Get("by")  // e.g. price
+	order := q.Get("order")                   // empty, asc(...) or desc(...).
+	orderBy := q.Get("by")                    // e.g. price
+	cursor := q.Get("cursor")                 // the next_cursor of a previous list.
+	include := splitColumns(q.Get("include")) // e.g. category,ratings.user
+	filter := parseFilters(q)
+
+	if cursor != "" {
+		offset = 0
+	}
+
+	return ListOptions{Offset: offset, Limit: limit, Order: order, OrderByColumn: orderBy, Filter: filter, Cursor: cursor, Include: include}
+}
 
-	return ListOptions{Offset: offset, Limit: limit, Order: order, OrderByColumn: orderBy}
+// Page holds a set of records and the cursor to fetch the next set.
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"` // empty on the last page.
 }
 
 // List binds one or more records from the database to the "dest".
 // If the record supports ordering then it will sort by the `Sorted.OrderBy` column name(s).
 // Use the "order" input parameter to set a descending order ("DESC").
 func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOptions) error {
+	opts, err := r.prepareListOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	q, args := opts.BuildQuery()
+	if err = r.db.Select(ctx, dest, q, args...); err != nil {
+		return err
+	}
+
+	return r.preloadInto(ctx, dest, opts.Include)
+}
+
+// ListPage same as `List` but it returns a `Page` of the "dest"
+// and the cursor of the next set of records, if "dest" is full (see `ListOptions.Limit`).
+func (r *Repository) ListPage(ctx context.Context, dest interface{}, opts ListOptions) (Page, error) {
+	opts, err := r.prepareListOptions(ctx, opts)
+	if err != nil {
+		return Page{}, err
+	}
+
+	q, args := opts.BuildQuery()
+	if err = r.db.Select(ctx, dest, q, args...); err != nil {
+		return Page{}, err
+	}
+
+	if err = r.preloadInto(ctx, dest, opts.Include); err != nil {
+		return Page{}, err
+	}
+
+	return Page{Items: dest, NextCursor: NextCursor(dest, opts)}, nil
+}
+
+func (r *Repository) prepareListOptions(ctx context.Context, opts ListOptions) (ListOptions, error) {
 	// Set table and order by column from record info for `List` by options
 	// so it can be more flexible to perform read-only calls of other table's too.
 	if opts.Table == "" {
@@ -173,9 +445,73 @@ func (r *Repository) List(ctx context.Context, dest interface{}, opts ListOption
 			opts.OrderByColumn = b.SortBy()
 		}
 	}
+	if opts.PrimaryKey == "" && opts.Table == r.rec.TableName() {
+		opts.PrimaryKey = r.rec.PrimaryKey()
+	}
+	if opts.Dialect == nil {
+		opts.Dialect = r.Dialect()
+	}
 
-	q, args := opts.BuildQuery()
-	return r.db.Select(ctx, dest, q, args...)
+	if opts.Table == r.rec.TableName() {
+		opts.Include = append(append([]string{}, r.preload...), opts.Include...)
+	}
+
+	if err := r.validateListOptions(opts); err != nil {
+		return opts, err
+	}
+
+	if column := r.softDeleteScope(ctx); column != "" && !opts.IncludeDeleted && opts.Table == r.rec.TableName() {
+		opts.Filter = And(opts.Filter, IsNull(column))
+	}
+
+	return opts, nil
+}
+
+// validateListOptions makes sure that the columns of "opts"
+// are known columns of the record, so caller-supplied values,
+// e.g. the ?by= sort parameter, never reach the query as raw SQL.
+// Lists of other tables can only refer to plain column names.
+func (r *Repository) validateListOptions(opts ListOptions) error {
+	columns := splitColumns(opts.OrderByColumn)
+	if opts.WhereColumn != "" {
+		columns = append(columns, opts.WhereColumn)
+	}
+	if opts.Filter != nil {
+		columns = append(columns, opts.Filter.Columns()...)
+	}
+	if opts.PrimaryKey != "" {
+		columns = append(columns, opts.PrimaryKey)
+	}
+
+	if opts.Cursor != "" {
+		// Keyset pagination works on a single sort column plus the primary key.
+		if opts.PrimaryKey == "" || len(splitColumns(opts.OrderByColumn)) > 1 {
+			return ErrUnprocessable
+		}
+
+		if _, err := DecodeCursor(opts.Cursor); err != nil {
+			return ErrUnprocessable
+		}
+	}
+
+	if opts.Table != r.rec.TableName() {
+		if len(opts.Include) > 0 {
+			return ErrUnprocessable // relations are known only for the record's table.
+		}
+
+		for _, column := range columns {
+			if !isIdentifier(column) {
+				return ErrUnprocessable
+			}
+		}
+		return nil
+	}
+
+	if err := ValidateColumns(r.rec, columns...); err != nil {
+		return err
+	}
+
+	return ValidatePreload(r.rec, opts.Include...)
 }
 
 // ErrUnprocessable indicates error caused by invalid entity (entity's key-values).
@@ -188,24 +524,46 @@ var ErrUnprocessable = errors.New("invalid entity")
 
 // PartialUpdate accepts a columns schema and a key-value map to
 // update the record based on the given "id".
-// Note: Trivial string, int and boolean type validations are performed here.
+// Note: Trivial string, int and boolean type validations are performed here,
+// keys that are not columns of the record result to `ErrUnprocessable`.
+// A nil value sets the column to NULL, only if its field is nullable, see `IsNullable`.
+// The "updated_at" column, if any, is set to the current time.
+// The version column of a `Versioned` record is the version the caller has read,
+// the update returns `ErrConflict` if the stored record has a different one.
 func (r *Repository) PartialUpdate(ctx context.Context, id int64, schema map[string]reflect.Kind, attrs map[string]interface{}) (int, error) {
 	if len(schema) == 0 || len(attrs) == 0 {
 		return 0, nil
 	}
 
+	expected, attrs, err := SplitVersion(r.rec, attrs)
+	if err != nil {
+		return 0, ErrUnprocessable
+	}
+
+	for key := range attrs {
+		if _, ok := schema[key]; !ok || !HasColumn(r.rec, key) {
+			return 0, ErrUnprocessable
+		}
+	}
+
 	var (
 		keyLines []string
 		values   []interface{}
 	)
 
-	for key, kind := range schema {
+	for _, key := range sortedKeys(attrs) {
+		kind := schema[key]
 		v, ok := attrs[key]
 		if !ok {
 			continue
 		}
 
 		switch v.(type) {
+		case nil:
+			// explicit NULL, see `Nullable`.
+			if !IsNullable(r.rec, key) {
+				return 0, ErrUnprocessable
+			}
 		case string:
 			if kind != reflect.String {
 				return 0, ErrUnprocessable
@@ -218,6 +576,17 @@ func (r *Repository) PartialUpdate(ctx context.Context, id int64, schema map[str
 			if kind != reflect.Bool {
 				return 0, ErrUnprocessable
 			}
+		case []interface{}, map[string]interface{}:
+			// JSON columns, see `StringList` and `JSONObject`.
+			if kind != reflect.Slice && kind != reflect.Map {
+				return 0, ErrUnprocessable
+			}
+
+			b, err := json.Marshal(v)
+			if err != nil {
+				return 0, ErrUnprocessable
+			}
+			v = string(b)
 		}
 
 		keyLines = append(keyLines, fmt.Sprintf("%s = ?", key))
@@ -228,18 +597,251 @@ func (r *Repository) PartialUpdate(ctx context.Context, id int64, schema map[str
 		return 0, nil
 	}
 
-	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?;",
-		r.rec.TableName(), strings.Join(keyLines, ", "), r.rec.PrimaryKey())
+	if _, ok := attrs[UpdatedAtColumn]; !ok && HasColumn(r.rec, UpdatedAtColumn) {
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", UpdatedAtColumn))
+		values = append(values, time.Now())
+	}
+
+	return r.execUpdate(ctx, id, keyLines, values, expected)
+}
+
+// Update updates the "columns" of "rec", a pointer to a record, based on its primary key
+// and returns the affected number (0 when the record does not exist or it is soft deleted, otherwise 1).
+// It calls the `BeforeUpdateHook` of the record and sets its "updated_at" column, if any.
+// If "rec" is `Versioned` and its version is set then the stored record is updated
+// only if it has the same version, otherwise it returns `ErrConflict`.
+// On success "rec" is re-read, so it holds the new version.
+func (r *Repository) Update(ctx context.Context, rec interface{}, columns ...string) (int, error) {
+	record, ok := rec.(Record)
+	if !ok {
+		return 0, ErrUnprocessable
+	}
+
+	expected, _ := VersionOf(rec)
+	if err := BeforeUpdate(ctx, rec); err != nil {
+		return 0, err
+	}
+
+	id := PrimaryKeyOf(rec)
+	if id <= 0 {
+		return 0, ErrUnprocessable
+	}
+
+	var (
+		keyLines []string
+		values   []interface{}
+	)
+
+	for _, column := range columns {
+		if column == record.PrimaryKey() || column == UpdatedAtColumn || column == VersionColumnOf(record) {
+			continue // set below.
+		}
+
+		f, ok := fieldOf(rec, column)
+		if !ok {
+			return 0, ErrUnprocessable
+		}
+
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", column))
+		values = append(values, f.Interface())
+	}
+
+	if len(keyLines) == 0 {
+		return 0, nil
+	}
+
+	if HasColumn(record, UpdatedAtColumn) {
+		keyLines = append(keyLines, fmt.Sprintf("%s = ?", UpdatedAtColumn))
+		values = append(values, time.Now())
+	}
+
+	n, err := r.execUpdate(ctx, id, keyLines, values, expected)
+	if err != nil || n == 0 {
+		return n, err
+	}
+
+	return n, r.Unscoped().GetByID(WithPrimary(ctx), rec, id)
+}
+
+// execUpdate executes the UPDATE of the "keyLines" assignments of the "id" record,
+// a soft deleted record is not updated unless the Repository is `Unscoped`.
+// The integer version of a `Versioned` record is incremented and,
+// if "expected" is not nil, only the record with that version is updated.
+func (r *Repository) execUpdate(ctx context.Context, id int64, keyLines []string, values []interface{}, expected interface{}) (int, error) {
+	where := fmt.Sprintf("%s = ?", r.rec.PrimaryKey())
+	if column := r.softDeleteScope(ctx); column != "" {
+		where += fmt.Sprintf(" AND %s IS NULL", column)
+	}
+	args := append(values, id)
 
-	res, err := r.DB().Exec(ctx, q, append(values, id)...)
+	if column := VersionColumnOf(r.rec); column != "" {
+		if isCounter(r.rec) {
+			keyLines = append(keyLines, fmt.Sprintf("%s = %s + 1", column, column))
+		}
+
+		if expected != nil {
+			where += fmt.Sprintf(" AND %s = ?", column)
+			args = append(args, expected)
+		}
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s;", r.rec.TableName(), strings.Join(keyLines, ", "), where)
+
+	res, err := r.DB().Exec(ctx, r.Dialect().Rebind(q), args...)
 	if err != nil {
 		return 0, err
 	}
 
 	n := GetAffectedRows(res)
+	if n == 0 && expected != nil {
+		// the record is either missing or it has a different version.
+		var total int64
+		q = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = ?", r.rec.TableName(), r.rec.PrimaryKey())
+		if column := r.softDeleteScope(ctx); column != "" {
+			q += fmt.Sprintf(" AND %s IS NULL", column)
+		}
+		if err = r.db.Select(WithPrimary(ctx), &total, r.Dialect().Rebind(q), id); err != nil && err != ErrNoRows {
+			return 0, err
+		}
+
+		if total > 0 {
+			return 0, ErrConflict
+		}
+	}
+
 	return n, nil
 }
 
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ExecInsert executes the INSERT query "q", written with "?" placeholders,
+// and returns the generated primary key through a RETURNING clause
+// or `LastInsertId`, depending on the dialect.
+// On multi-row inserts it is the id of the first row.
+func (r *Repository) ExecInsert(ctx context.Context, q string, args ...interface{}) (int64, error) {
+	d := r.Dialect()
+	q = strings.TrimSuffix(strings.TrimSpace(q), ";")
+
+	if returning := d.Returning(r.rec.PrimaryKey()); returning != "" {
+		var id int64
+		// it is a write, see `WithPrimary`.
+		err := r.db.Get(WithPrimary(ctx), &id, d.Rebind(q+returning), args...)
+		return id, err
+	}
+
+	res, err := r.db.Exec(ctx, d.Rebind(q), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// ExecBatchInsert executes the multi-row INSERT query "q" of "n" rows,
+// written with "?" placeholders, and returns the generated primary keys in order.
+// On Postgres they are read back with RETURNING. On MySQL they are computed from `LastInsertId`,
+// which is the id of the first row, assuming the ids of a single multi-row INSERT are consecutive:
+// that holds only with auto_increment_increment = 1 and an innodb_autoinc_lock_mode of 0 or 1,
+// `ConnectMySQLWith` rejects any other server configuration.
+func (r *Repository) ExecBatchInsert(ctx context.Context, q string, n int, args ...interface{}) ([]int64, error) {
+	d := r.Dialect()
+	q = strings.TrimSuffix(strings.TrimSpace(q), ";")
+
+	if returning := d.Returning(r.rec.PrimaryKey()); returning != "" {
+		var ids []int64
+		err := r.db.Select(WithPrimary(ctx), &ids, d.Rebind(q+returning), args...)
+		return ids, err
+	}
+
+	res, err := r.db.Exec(ctx, d.Rebind(q), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = first + int64(i)
+	}
+
+	return ids, nil
+}
+
+// Insert inserts "rec", a pointer to a record of the repository's type,
+// with its `InsertColumnsOf` columns and returns its generated primary key.
+// It does not call the hooks of the record, see `BeforeInsert` and `AfterInsert`.
+func (r *Repository) Insert(ctx context.Context, rec interface{}) (int64, error) {
+	q, args, err := r.insertQuery([]interface{}{rec})
+	if err != nil {
+		return 0, err
+	}
+
+	return r.ExecInsert(ctx, q, args...)
+}
+
+// BatchInsert inserts the "records", pointers to records of the repository's type,
+// with a single multi-row INSERT and returns their generated primary keys in order.
+// It does not call the hooks of the records, see `BeforeInsert` and `AfterInsert`.
+func (r *Repository) BatchInsert(ctx context.Context, records []interface{}) ([]int64, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	q, args, err := r.insertQuery(records)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ExecBatchInsert(ctx, q, len(records), args...)
+}
+
+// insertQuery returns the INSERT query of the "records" and its arguments.
+func (r *Repository) insertQuery(records []interface{}) (string, []interface{}, error) {
+	var (
+		columns     = InsertColumnsOf(r.rec)
+		valuesLines = make([]string, 0, len(records))
+		args        = make([]interface{}, 0, len(records)*len(columns))
+	)
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	for _, rec := range records {
+		if reflect.TypeOf(rec) != reflect.PtrTo(indirectType(reflect.TypeOf(r.rec))) || reflect.ValueOf(rec).IsNil() {
+			return "", nil, ErrUnprocessable
+		}
+
+		valuesLines = append(valuesLines, placeholders)
+		for _, column := range columns {
+			f, _ := fieldOf(rec, column)
+			args = append(args, f.Interface())
+		}
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s;", r.rec.TableName(), strings.Join(columns, ", "), strings.Join(valuesLines, ", "))
+	return q, args, nil
+}
+
 // GetAffectedRows returns the number of affected rows after
 // a DELETE or UPDATE operation.
 func GetAffectedRows(result sql.Result) int {
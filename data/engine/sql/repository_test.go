@@ -0,0 +1,59 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type softItem struct {
+	ID        int64      `db:"id"`
+	Name      string     `db:"name"`
+	DeletedAt *time.Time `db:"deleted_at"`
+}
+
+func (softItem) TableName() string        { return "soft_items" }
+func (softItem) PrimaryKey() string       { return "id" }
+func (softItem) SoftDeleteColumn() string { return "deleted_at" }
+
+// execsDB is a `Database` which records the executed writes and affects no rows.
+type execsDB struct {
+	execs []string
+}
+
+func (db *execsDB) Get(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	return ErrNoRows
+}
+
+func (db *execsDB) Select(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	return ErrNoRows
+}
+
+func (db *execsDB) Exec(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	db.execs = append(db.execs, q)
+	return affected(0), nil
+}
+
+func TestUpdateSoftDeleteScope(t *testing.T) {
+	ctx := context.Background()
+	db := new(execsDB)
+	repo := NewRepository(db, new(softItem))
+	schema := UpdateSchemaOf(new(softItem))
+
+	repo.Update(ctx, &softItem{ID: 1, Name: "a"}, "name")
+	repo.PartialUpdate(ctx, 1, schema, map[string]interface{}{"name": "a"})
+	repo.Unscoped().Update(ctx, &softItem{ID: 1, Name: "a"}, "name")
+	repo.Update(WithDeleted(ctx), &softItem{ID: 1, Name: "a"}, "name")
+
+	expected := []string{
+		"UPDATE soft_items SET name = ? WHERE id = ? AND deleted_at IS NULL;",
+		"UPDATE soft_items SET name = ? WHERE id = ? AND deleted_at IS NULL;",
+		"UPDATE soft_items SET name = ? WHERE id = ?;",
+		"UPDATE soft_items SET name = ? WHERE id = ?;",
+	}
+	if !reflect.DeepEqual(db.execs, expected) {
+		t.Fatalf("expected queries %q but got %q", expected, db.execs)
+	}
+}
@@ -0,0 +1,216 @@
+package sql
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structFields is the result of the `db` tags reflection of a struct type.
+type structFields struct {
+	index  map[string][]int // column name to field index.
+	schema *schema          // the columns in field order.
+}
+
+var fieldsCache sync.Map // map[reflect.Type]*structFields
+
+// FieldsOf returns the field index of each `db` tag column of the "typ" struct
+// (or pointer to struct), fields of embedded structs are included
+// and a field of an outer struct wins over the same column of an embedded one.
+// Embedded pointers to structs are skipped, as they can't be bound without allocation.
+// The result is cached per type and shared by the storage engines, it must not be modified.
+func FieldsOf(typ reflect.Type) map[string][]int {
+	return structFieldsOf(indirectType(typ)).index
+}
+
+func structFieldsOf(typ reflect.Type) *structFields {
+	if f, ok := fieldsCache.Load(typ); ok {
+		return f.(*structFields)
+	}
+
+	index := make(map[string][]int)
+	var columns []string
+	if typ.Kind() == reflect.Struct {
+		columns = collectFields(typ, nil, index, columns)
+	}
+
+	f := &structFields{index: index, schema: newSchema(columns)}
+	fieldsCache.Store(typ, f)
+	return f
+}
+
+func collectFields(typ reflect.Type, parent []int, index map[string][]int, columns []string) []string {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		idx := append(append([]int{}, parent...), i)
+		tag := field.Tag.Get("db")
+
+		if field.Anonymous && tag == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			if field.Type.Kind() == reflect.Struct {
+				columns = collectFields(field.Type, idx, index, columns)
+			}
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		existing, exists := index[name]
+		if !exists {
+			columns = append(columns, name)
+		}
+		if !exists || len(idx) < len(existing) { // outer fields win.
+			index[name] = idx
+		}
+	}
+
+	return columns
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// isStructDest reports whether "typ" is a struct which should be bound by its `db` tags,
+// rather than scanned as a single value (e.g. time.Time or a `sql.Scanner`).
+func isStructDest(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || typ == timeType {
+		return false
+	}
+
+	return !reflect.PtrTo(typ).Implements(scannerType)
+}
+
+// ScanRow binds the current row of "rows" to "dest", a pointer to a struct.
+// The result columns are mapped to the struct fields by their `db` tag,
+// columns without a field are skipped.
+func ScanRow(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || !isStructDest(v.Elem().Type()) {
+		return ErrUnprocessable
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	return scanStruct(rows, columns, v.Elem())
+}
+
+// ScanAll binds all the rows of "rows" to "dest",
+// a pointer to a slice of structs or of pointers to structs, see `ScanRow`,
+// or a pointer to a slice of single column values, e.g. []int64.
+// Like the `Scannable` implementations, it returns `ErrNoRows` when there are no rows.
+func ScanAll(rows *sql.Rows, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrUnprocessable
+	}
+
+	list := v.Elem()
+	elemType := list.Type().Elem()
+	structType := indirectType(elemType)
+	isStruct := isStructDest(structType)
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for rows.Next() {
+		elem := reflect.New(structType)
+		if isStruct {
+			err = scanStruct(rows, columns, elem.Elem())
+		} else {
+			err = rows.Scan(elem.Interface())
+		}
+		if err != nil {
+			return err
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			list = reflect.Append(list, elem)
+		} else {
+			list = reflect.Append(list, elem.Elem())
+		}
+		n++
+	}
+
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrNoRows
+	}
+
+	v.Elem().Set(list)
+	return nil
+}
+
+func scanStruct(rows *sql.Rows, columns []string, v reflect.Value) error {
+	fields := FieldsOf(v.Type())
+	targets := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, ok := fields[column]
+		if !ok {
+			targets[i] = new(sql.RawBytes) // discard.
+			continue
+		}
+
+		targets[i] = v.FieldByIndex(index).Addr().Interface()
+	}
+
+	return rows.Scan(targets...)
+}
+
+// scanAll binds the "rows" to the "dest" of a `Database.Select` call:
+// a `Scannable`, a pointer to a slice or to a struct, or any value accepted by `sql.Rows.Scan`.
+func scanAll(rows *sql.Rows, dest interface{}) error {
+	if scannable, ok := dest.(Scannable); ok {
+		return scannable.Scan(rows)
+	}
+
+	if dest == nil {
+		return ErrUnprocessable
+	}
+
+	typ := reflect.TypeOf(dest)
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Slice &&
+		typ.Elem().Elem().Kind() != reflect.Uint8 && !typ.Implements(scannerType) {
+		return ScanAll(rows, dest)
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+
+	return scanOne(rows, dest)
+}
+
+// scanOne binds the current row to the "dest" of a `Database.Get` call.
+func scanOne(rows *sql.Rows, dest interface{}) error {
+	if scannable, ok := dest.(Scannable); ok {
+		return scannable.Scan(rows)
+	}
+
+	if dest == nil {
+		return ErrUnprocessable
+	}
+
+	if typ := reflect.TypeOf(dest); typ.Kind() == reflect.Ptr && isStructDest(typ.Elem()) {
+		return ScanRow(rows, dest)
+	}
+
+	return rows.Scan(dest)
+}
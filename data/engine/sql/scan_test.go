@@ -0,0 +1,44 @@
+package sql
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fieldsBase struct {
+	ID        int64  `db:"id"`
+	CreatedAt string `db:"created_at"`
+}
+
+type fieldsExtra struct {
+	Note string `db:"note"`
+}
+
+type fieldsRecord struct {
+	fieldsBase
+	*fieldsExtra
+	Name    string `db:"name,omitempty"`
+	ID      int64  `db:"id"`
+	Ignored string `db:"-"`
+	Plain   string
+}
+
+func (fieldsRecord) TableName() string  { return "fields" }
+func (fieldsRecord) PrimaryKey() string { return "id" }
+
+func TestFieldsOf(t *testing.T) {
+	fields := FieldsOf(reflect.TypeOf(&fieldsRecord{}))
+
+	expected := map[string][]int{
+		"id":         {3}, // the outer field wins.
+		"created_at": {0, 1},
+		"name":       {2},
+	}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Fatalf("expected fields %v but got %v", expected, fields)
+	}
+
+	if got, want := ColumnsOf(fieldsRecord{}), []string{"id", "created_at", "name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected columns %v but got %v", want, got)
+	}
+}
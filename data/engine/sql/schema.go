@@ -0,0 +1,123 @@
+package sql
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Columned can be optionally implemented by a Record
+// to declare its column names explicitly.
+// Records that don't implement it have their columns
+// resolved from the `db` struct tags of their fields.
+type Columned interface {
+	Columns() []string
+}
+
+// schema holds the column names of a record type.
+type schema struct {
+	columns []string
+	set     map[string]struct{}
+}
+
+func newSchema(columns []string) *schema {
+	set := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		set[column] = struct{}{}
+	}
+
+	return &schema{columns: columns, set: set}
+}
+
+func schemaOf(rec Record) *schema {
+	if c, ok := rec.(Columned); ok {
+		return newSchema(c.Columns())
+	}
+
+	return structFieldsOf(indirectType(reflect.TypeOf(rec))).schema
+}
+
+// ColumnsOf returns the column names of a record.
+// The result of the `db` tags reflection is cached per record type.
+func ColumnsOf(rec Record) []string {
+	return schemaOf(rec).columns
+}
+
+// HasColumn reports whether "column" is a column of the "rec" record.
+func HasColumn(rec Record, column string) bool {
+	_, ok := schemaOf(rec).set[column]
+	return ok
+}
+
+// ValidateColumns returns `ErrUnprocessable` if any of the "columns"
+// is not a column of the "rec" record.
+func ValidateColumns(rec Record, columns ...string) error {
+	set := schemaOf(rec).set
+	for _, column := range columns {
+		if _, ok := set[column]; !ok {
+			return ErrUnprocessable
+		}
+	}
+
+	return nil
+}
+
+// InsertColumnsOf returns the columns a new "rec" record is inserted with,
+// all of its columns but the primary key, which is generated.
+func InsertColumnsOf(rec Record) []string {
+	return withoutStrings(ColumnsOf(rec), rec.PrimaryKey())
+}
+
+// UpdateColumnsOf returns the columns a full update of the "rec" record writes,
+// all of its columns but the primary key, the creation and deletion times and the version,
+// "updated_at" and the version are set by the update itself, see `Repository.Update`.
+func UpdateColumnsOf(rec Record) []string {
+	return withoutStrings(ColumnsOf(rec), rec.PrimaryKey(), CreatedAtColumn, UpdatedAtColumn,
+		SoftDeleteColumnOf(rec), VersionColumnOf(rec))
+}
+
+// UpdateSchemaOf returns the `Repository.PartialUpdate` schema of the "rec" record,
+// the kinds of the values its `UpdateColumnsOf` accept as decoded from JSON:
+// time, null and byte slice columns accept strings and integer columns accept any integer kind.
+func UpdateSchemaOf(rec Record) map[string]reflect.Kind {
+	typ := indirectType(reflect.TypeOf(rec))
+	fields := FieldsOf(typ)
+
+	schema := make(map[string]reflect.Kind)
+	for _, column := range UpdateColumnsOf(rec) {
+		index, ok := fields[column]
+		if !ok {
+			continue
+		}
+
+		ftyp := indirectType(typ.FieldByIndex(index).Type)
+		switch kind := ftyp.Kind(); {
+		case isIntKind(kind):
+			schema[column] = reflect.Int
+		case kind == reflect.Struct, kind == reflect.Slice && ftyp.Elem().Kind() == reflect.Uint8:
+			schema[column] = reflect.String
+		default:
+			schema[column] = kind
+		}
+	}
+
+	return schema
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// splitColumns splits a comma separated list of column names,
+// e.g. the result of `Sorted.SortBy`.
+func splitColumns(s string) (columns []string) {
+	for _, column := range strings.Split(s, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,74 @@
+package sql
+
+import (
+	"context"
+	"reflect"
+)
+
+// SoftDeletable can be optionally implemented by a Record
+// to mark its records as deleted, by setting a time column, instead of removing them.
+// Soft deleted records are hidden from `Repository.GetByID`, `GetByAttrs`, `GetAll`, `Count` and `List`,
+// unless the context is created by `WithDeleted`, the repository is `Unscoped`
+// or the `ListOptions.IncludeDeleted` field is true.
+// They can be brought back with `Repository.Restore` and removed with `Repository.Purge`.
+type SoftDeletable interface {
+	// SoftDeleteColumn returns the nullable time column
+	// which holds the deletion time, e.g. "deleted_at".
+	SoftDeleteColumn() string
+}
+
+// SoftDeleteColumnOf returns the soft delete column of the "rec" record
+// or an empty string if it does not implement the `SoftDeletable` interface.
+func SoftDeleteColumnOf(rec Record) string {
+	if s, ok := rec.(SoftDeletable); ok {
+		return s.SoftDeleteColumn()
+	}
+
+	return ""
+}
+
+type includeDeletedKey struct{}
+
+// WithDeleted returns a copy of "ctx" which makes
+// the repositories and storage engines include the soft deleted records.
+func WithDeleted(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+// IncludesDeleted reports whether "ctx" is created by `WithDeleted`.
+func IncludesDeleted(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	v, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return v
+}
+
+// IsDeleted reports whether "rec", a pointer to a soft deletable record, is soft deleted.
+func IsDeleted(rec interface{}) bool {
+	r, ok := rec.(Record)
+	if !ok {
+		return false
+	}
+
+	column := SoftDeleteColumnOf(r)
+	if column == "" {
+		return false
+	}
+
+	f, ok := fieldOf(rec, column)
+	if !ok {
+		return false
+	}
+
+	if n, ok := f.Interface().(Nullable); ok {
+		return !n.IsNull()
+	}
+
+	return f.Kind() == reflect.Ptr && !f.IsNil()
+}
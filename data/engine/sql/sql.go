@@ -12,6 +12,14 @@ type Database interface {
 	Exec(ctx context.Context, q string, args ...interface{}) (sql.Result, error)
 }
 
+// Transactional is an interface which a database(sql)
+// that supports transactions should implement.
+// The "tx" Database passed to "fn" should be used for all the queries
+// that belong to the same unit of work, see `Repository.Join`.
+type Transactional interface {
+	WithTx(ctx context.Context, fn func(tx Database) error) error
+}
+
 // Record should represent a database record.
 // It holds the table name and the primary key.
 // Entities should implement that
@@ -35,6 +43,8 @@ type Sorted interface {
 }
 
 // Scannable for go structs to bind their fields.
+// It is optional, structs and slices of structs are bound
+// by their `db` field tags when they don't implement it, see `ScanRow` and `ScanAll`.
 type Scannable interface {
 	Scan(*sql.Rows) error
 }
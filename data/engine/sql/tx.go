@@ -0,0 +1,150 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Tx holds an in-flight transaction.
+// It implements the `Database` interface so it can be passed
+// to `Repository.Join` in order to run repository queries in the same unit of work.
+// See the `MySQL.BeginTx` and `MySQL.WithTx` methods.
+type Tx struct {
+	Conn    *sql.Tx
+	dialect Dialect
+	timeout time.Duration // the default timeout of the queries, see `ConnOptions.QueryTimeout`.
+
+	mu          sync.Mutex
+	afterCommit []func() // see `AfterCommit`.
+}
+
+var (
+	_ Database      = (*Tx)(nil)
+	_ Transactional = (*Tx)(nil)
+	_ Dialecter     = (*Tx)(nil)
+	_ Committer     = (*Tx)(nil)
+)
+
+// Committer is implemented by the databases which run inside a transaction,
+// e.g. `Tx`, to run a function once the transaction is committed, see the `AfterCommit` function.
+type Committer interface {
+	AfterCommit(fn func())
+}
+
+// AfterCommit calls "fn" after the transaction "db" runs inside is committed,
+// it is never called if the transaction is rolled back.
+// If "db" is not a transaction, e.g. a `MySQL`, "fn" is called right away.
+func AfterCommit(db Database, fn func()) {
+	if c, ok := db.(Committer); ok {
+		c.AfterCommit(fn)
+		return
+	}
+
+	fn()
+}
+
+// ErrTxUnsupported is returned by `Repository.WithTx`
+// when the underline database does not support transactions.
+var ErrTxUnsupported = errors.New("transactions are not supported")
+
+// Select performs the SELECT query inside the transaction.
+func (tx *Tx) Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
+
+	return selectContext(ctx, tx.Conn, dest, query, args...)
+}
+
+// Get same as `Select` but it moves the cursor to the first result.
+func (tx *Tx) Get(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
+
+	return getContext(ctx, tx.Conn, dest, query, args...)
+}
+
+// Exec executes a query inside the transaction. It does not return any rows.
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, tx.timeout)
+	defer cancel()
+
+	return tx.Conn.ExecContext(ctx, query, args...)
+}
+
+// Dialect implements the `Dialecter` interface,
+// it returns the dialect of the database which started the transaction.
+func (tx *Tx) Dialect() Dialect {
+	if tx.dialect == nil {
+		return MySQLDialect
+	}
+
+	return tx.dialect
+}
+
+// AfterCommit implements the `Committer` interface,
+// "fn" is called by a successful `Commit` and dropped by `Rollback`.
+func (tx *Tx) AfterCommit(fn func()) {
+	tx.mu.Lock()
+	tx.afterCommit = append(tx.afterCommit, fn)
+	tx.mu.Unlock()
+}
+
+// callbacks returns and removes the `AfterCommit` functions.
+func (tx *Tx) callbacks() []func() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	fns := tx.afterCommit
+	tx.afterCommit = nil
+	return fns
+}
+
+// Commit commits the transaction and then calls the `AfterCommit` functions.
+func (tx *Tx) Commit() error {
+	fns := tx.callbacks()
+	if err := tx.Conn.Commit(); err != nil {
+		return err
+	}
+
+	for _, fn := range fns {
+		fn()
+	}
+
+	return nil
+}
+
+// Rollback aborts the transaction, the `AfterCommit` functions are never called.
+func (tx *Tx) Rollback() error {
+	tx.callbacks()
+	return tx.Conn.Rollback()
+}
+
+// WithTx joins the current transaction, "fn" is called with this Tx
+// and commit or rollback is left to the outer `WithTx` call.
+func (tx *Tx) WithTx(ctx context.Context, fn func(tx Database) error) error {
+	return fn(tx)
+}
+
+// run calls "fn" and commits the transaction on success.
+// On error or panic the transaction is rolled back,
+// the panic is re-thrown after the rollback.
+func (tx *Tx) run(fn func(tx Database) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+
+		err = tx.Commit()
+	}()
+
+	return fn(tx)
+}
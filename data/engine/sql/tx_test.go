@@ -0,0 +1,82 @@
+package sql
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// txDriver is a `driver.Driver` whose transactions fail to commit if "commitErr" is set.
+type txDriver struct{ commitErr error }
+
+func (d *txDriver) Open(string) (driver.Conn, error) { return txConn{d}, nil }
+
+type txConn struct{ d *txDriver }
+
+func (c txConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c txConn) Close() error                        { return nil }
+func (c txConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c txConn) Commit() error                       { return c.d.commitErr }
+func (c txConn) Rollback() error                     { return nil }
+
+var testTxDriver = new(txDriver)
+
+func init() {
+	sql.Register("txtest", testTxDriver)
+}
+
+func TestTxAfterCommit(t *testing.T) {
+	conn, err := sql.Open("txtest", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	commitErr := errors.New("commit failed")
+	tests := []struct {
+		name      string
+		fn        func(tx Database) error
+		commitErr error
+		expected  []string
+	}{
+		{"commit", func(Database) error { return nil }, nil, []string{"tx", "instrumented"}},
+		{"rollback", func(Database) error { return errors.New("rollback") }, nil, nil},
+		{"failed commit", func(Database) error { return nil }, commitErr, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testTxDriver.commitErr = tt.commitErr
+			defer func() { testTxDriver.commitErr = nil }()
+
+			stdTx, err := conn.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var called []string
+			tx := &Tx{Conn: stdTx}
+			tx.run(func(db Database) error {
+				AfterCommit(db, func() { called = append(called, "tx") })
+				AfterCommit(&Instrumented{db: db}, func() { called = append(called, "instrumented") })
+				if len(called) > 0 {
+					t.Fatalf("expected no calls before the commit but got %v", called)
+				}
+
+				return tt.fn(db)
+			})
+
+			if !reflect.DeepEqual(called, tt.expected) {
+				t.Fatalf("expected calls %v but got %v", tt.expected, called)
+			}
+		})
+	}
+
+	called := false
+	AfterCommit(namedDB{}, func() { called = true })
+	if !called {
+		t.Fatal("expected a call right away outside of a transaction")
+	}
+}
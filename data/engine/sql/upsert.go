@@ -0,0 +1,528 @@
+package sql
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// UpsertOptions holds the settings of `Repository.Upsert`.
+type UpsertOptions struct {
+	// ConflictKeys are the columns which identify the stored record of an upserted one,
+	// they should be covered by a unique index. Defaults to the primary key.
+	ConflictKeys []string
+	// Columns are the inserted columns, defaults to all the columns of the record.
+	// The primary key is inserted only when it is set.
+	Columns []string
+	// UpdateColumns are the columns which are updated when a record exists,
+	// defaults to the `Columns` except the conflict keys, "created_at",
+	// the soft delete and the integer version columns. The integer version is always incremented.
+	UpdateColumns []string
+	// DoNothing keeps the existing records as they are, they are reported as skipped.
+	DoNothing bool
+	// ChunkSize is the maximum number of records of a single statement, defaults to `DefaultUpsertChunkSize`.
+	ChunkSize int
+}
+
+// DefaultUpsertChunkSize is the default `UpsertOptions.ChunkSize`.
+var DefaultUpsertChunkSize = 500
+
+// maxPlaceholders is the maximum number of arguments of a single statement on MySQL and Postgres.
+const maxPlaceholders = 65535
+
+// UpsertStatus is the outcome of an upserted record.
+type UpsertStatus string
+
+const (
+	// UpsertInserted is the status of a new record.
+	UpsertInserted UpsertStatus = "inserted"
+	// UpsertUpdated is the status of an existing record which has been changed.
+	UpsertUpdated UpsertStatus = "updated"
+	// UpsertSkipped is the status of an existing record which is left as it is,
+	// because it has no changes or `UpsertOptions.DoNothing` is true.
+	UpsertSkipped UpsertStatus = "skipped"
+	// UpsertFailed is the status of a record which could not be stored, see `UpsertResult.Err`.
+	UpsertFailed UpsertStatus = "failed"
+)
+
+// UpsertResult is the outcome of the upserted record at "Index".
+type UpsertResult struct {
+	Index  int          `json:"index"`
+	ID     int64        `json:"id,omitempty"`
+	Status UpsertStatus `json:"status"`
+	Err    error        `json:"-"`
+	Reason string       `json:"reason,omitempty"` // the text of the Err.
+}
+
+// UpsertReport holds the results of `Repository.Upsert`, one for each record, in order.
+type UpsertReport struct {
+	Results  []UpsertResult `json:"results"`
+	Inserted int            `json:"inserted"`
+	Updated  int            `json:"updated"`
+	Skipped  int            `json:"skipped"`
+	Failed   int            `json:"failed"`
+}
+
+// NewUpsertReport returns a new report of "n" records.
+func NewUpsertReport(n int) UpsertReport {
+	report := UpsertReport{Results: make([]UpsertResult, n)}
+	for i := range report.Results {
+		report.Results[i].Index = i
+	}
+
+	return report
+}
+
+// Set sets the result of the record at "index" and updates the totals.
+func (r *UpsertReport) Set(index int, id int64, status UpsertStatus, err error) {
+	r.count(r.Results[index].Status, -1)
+	r.count(status, 1)
+
+	res := UpsertResult{Index: index, ID: id, Status: status, Err: err}
+	if err != nil {
+		res.Reason = err.Error()
+	}
+	r.Results[index] = res
+}
+
+func (r *UpsertReport) count(status UpsertStatus, delta int) {
+	switch status {
+	case UpsertInserted:
+		r.Inserted += delta
+	case UpsertUpdated:
+		r.Updated += delta
+	case UpsertSkipped:
+		r.Skipped += delta
+	case UpsertFailed:
+		r.Failed += delta
+	}
+}
+
+// ErrDuplicateConflictKey is the reason of a failed upserted record
+// whose conflict keys are the same as the ones of a previous record of the batch.
+var ErrDuplicateConflictKey = errors.New("sql: duplicate conflict key in the batch")
+
+// ResolveUpsert returns a copy of "opts" with the defaults of the "rec" record.
+// It returns `ErrUnprocessable` if any of the columns is not a column of the record.
+func ResolveUpsert(rec Record, opts UpsertOptions) (UpsertOptions, error) {
+	pk := rec.PrimaryKey()
+	if len(opts.ConflictKeys) == 0 {
+		opts.ConflictKeys = []string{pk}
+	}
+
+	if len(opts.Columns) == 0 {
+		opts.Columns = ColumnsOf(rec)
+	}
+	opts.Columns = withoutStrings(opts.Columns, pk) // see `UpsertOptions.Columns`.
+
+	ignored := append([]string{pk}, opts.ConflictKeys...)
+	if isCounter(rec) {
+		ignored = append(ignored, VersionColumnOf(rec))
+	}
+	if opts.UpdateColumns == nil {
+		opts.UpdateColumns = withoutStrings(opts.Columns, append(ignored, CreatedAtColumn, SoftDeleteColumnOf(rec))...)
+	} else {
+		opts.UpdateColumns = withoutStrings(opts.UpdateColumns, ignored...)
+	}
+
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultUpsertChunkSize
+	}
+	if max := maxPlaceholders / (len(opts.Columns) + 1); opts.ChunkSize > max {
+		opts.ChunkSize = max
+	}
+
+	columns := append(append(append([]string{}, opts.ConflictKeys...), opts.Columns...), opts.UpdateColumns...)
+	if err := ValidateColumns(rec, columns...); err != nil {
+		return opts, err
+	}
+
+	return opts, nil
+}
+
+// ChangedColumns returns the "columns" whose values differ between "stored" and "rec",
+// both pointers to records of the same type. The "updated_at" column is ignored.
+func ChangedColumns(stored, rec interface{}, columns []string) []string {
+	var changed []string
+	for _, column := range columns {
+		if column == UpdatedAtColumn {
+			continue
+		}
+
+		a, okA := fieldOf(stored, column)
+		b, okB := fieldOf(rec, column)
+		if !okA || !okB || !sameValue(a.Interface(), b.Interface()) {
+			changed = append(changed, column)
+		}
+	}
+
+	return changed
+}
+
+func sameValue(a, b interface{}) bool {
+	a, b = plainValue(a), plainValue(b)
+	if ta, ok := a.(time.Time); ok {
+		tb, ok := b.(time.Time)
+		return ok && ta.Equal(tb)
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// plainValue unwraps the pointers and the `driver.Valuer` values, e.g. `NullString`.
+func plainValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+
+		value, err := valuer.Value()
+		if err == nil {
+			return value
+		}
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		return plainValue(rv.Elem().Interface())
+	}
+
+	return v
+}
+
+// upsertRow is a record of an `Upsert` batch.
+type upsertRow struct {
+	index int
+	ptr   interface{} // a pointer to a copy of the record.
+	key   string      // the values of the conflict keys, empty if it cannot conflict.
+}
+
+// Upsert inserts the "records", values or pointers of the repository's record type,
+// and updates the stored records with the same `UpsertOptions.ConflictKeys` instead.
+// The records run through the `BeforeInsert` hooks and their `ValidateInsert` method, if any.
+//
+// The records are written in chunks of `UpsertOptions.ChunkSize`: the stored records
+// of each chunk are read first, so the unchanged ones are skipped, then a single
+// INSERT with the dialect's upsert clause, e.g. ON DUPLICATE KEY UPDATE, writes the rest.
+// If a chunk fails its records are retried one by one, so the report holds the reason
+// of each failed record. Chunks are not written in a single transaction,
+// use `WithTx` and `Join` to make the whole batch atomic.
+//
+// The returned error is not nil only when the batch could not proceed, e.g. a lost connection,
+// then the report holds the results of the records written so far.
+func (r *Repository) Upsert(ctx context.Context, records []interface{}, opts UpsertOptions) (UpsertReport, error) {
+	report := NewUpsertReport(len(records))
+
+	opts, err := ResolveUpsert(r.rec, opts)
+	if err != nil {
+		return report, err
+	}
+
+	var (
+		pk       = r.rec.PrimaryKey()
+		keyIsPK  = containsString(opts.ConflictKeys, pk)
+		withPK   []upsertRow // records with a primary key, it is inserted too.
+		withoutP []upsertRow
+		seen     = make(map[string]bool)
+	)
+
+	for i, v := range records {
+		ptr, err := r.upsertRecord(ctx, v)
+		if err != nil {
+			report.Set(i, 0, UpsertFailed, err)
+			continue
+		}
+
+		row := upsertRow{index: i, ptr: ptr}
+		hasPK := PrimaryKeyOf(ptr) > 0
+		if hasPK || !keyIsPK {
+			row.key = ConflictKeyOf(ptr, opts.ConflictKeys)
+			if seen[row.key] {
+				report.Set(i, 0, UpsertFailed, ErrDuplicateConflictKey)
+				continue
+			}
+			seen[row.key] = true
+		}
+
+		if hasPK {
+			withPK = append(withPK, row)
+		} else {
+			withoutP = append(withoutP, row)
+		}
+	}
+
+	for _, group := range []struct {
+		rows    []upsertRow
+		columns []string
+	}{
+		{withPK, append([]string{pk}, opts.Columns...)},
+		{withoutP, opts.Columns},
+	} {
+		for start := 0; start < len(group.rows); start += opts.ChunkSize {
+			end := start + opts.ChunkSize
+			if end > len(group.rows) {
+				end = len(group.rows)
+			}
+
+			if err = r.upsertChunk(ctx, opts, group.columns, group.rows[start:end], &report); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// insertValidator is implemented by the records that check their required fields before insert.
+type insertValidator interface {
+	ValidateInsert() bool
+}
+
+// upsertRecord returns a pointer to a copy of "v", after its `BeforeInsert` hooks and validation.
+func (r *Repository) upsertRecord(ctx context.Context, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, ErrUnprocessable
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Type() != indirectType(reflect.TypeOf(r.rec)) {
+		return nil, ErrUnprocessable
+	}
+
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	rec := ptr.Interface()
+
+	if err := BeforeInsert(ctx, rec); err != nil {
+		return nil, err
+	}
+
+	if validator, ok := rec.(insertValidator); ok && !validator.ValidateInsert() {
+		return nil, ErrUnprocessable
+	}
+
+	return rec, nil
+}
+
+// upsertChunk writes the "rows" of a single chunk and sets their results.
+func (r *Repository) upsertChunk(ctx context.Context, opts UpsertOptions, columns []string, rows []upsertRow, report *UpsertReport) error {
+	stored, err := r.storedByKeys(ctx, opts.ConflictKeys, rows)
+	if err != nil {
+		return err
+	}
+
+	var pending []upsertRow
+	for _, row := range rows {
+		existing, ok := stored[row.key]
+		if !ok {
+			pending = append(pending, row)
+			continue
+		}
+
+		id := PrimaryKeyOf(existing)
+		if opts.DoNothing || len(ChangedColumns(existing, row.ptr, opts.UpdateColumns)) == 0 {
+			report.Set(row.index, id, UpsertSkipped, nil)
+			continue
+		}
+
+		pending = append(pending, row)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids, err := r.execUpsert(ctx, opts, columns, pending)
+	if err != nil {
+		if isConnError(err) || ctx != nil && ctx.Err() != nil {
+			return err
+		}
+
+		if len(pending) == 1 {
+			report.Set(pending[0].index, 0, UpsertFailed, err)
+			return nil
+		}
+
+		// find the failed records and their reason.
+		for _, row := range pending {
+			if err = r.upsertChunk(ctx, opts, columns, []upsertRow{row}, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i, row := range pending {
+		status := UpsertInserted
+		if existing, ok := stored[row.key]; ok {
+			status = UpsertUpdated
+			ids[i] = PrimaryKeyOf(existing)
+		}
+
+		if status == UpsertInserted {
+			if err = AfterInsert(ctx, row.ptr, ids[i]); err != nil {
+				report.Set(row.index, ids[i], UpsertFailed, err)
+				continue
+			}
+		}
+
+		report.Set(row.index, ids[i], status, nil)
+	}
+
+	return nil
+}
+
+// execUpsert executes the INSERT of the "rows" and returns their primary keys, in order.
+func (r *Repository) execUpsert(ctx context.Context, opts UpsertOptions, columns []string, rows []upsertRow) ([]int64, error) {
+	var (
+		valuesLines []string
+		args        []interface{}
+	)
+
+	placeholders := "(" + strings.TrimSuffix(strings.Repeat("?,", len(columns)), ",") + ")"
+	for _, row := range rows {
+		valuesLines = append(valuesLines, placeholders)
+		for _, column := range columns {
+			f, _ := fieldOf(row.ptr, column)
+			args = append(args, f.Interface())
+		}
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", r.rec.TableName(), strings.Join(columns, ", "), strings.Join(valuesLines, ", "))
+
+	if rows[0].key == "" {
+		// new records which cannot conflict, their ids are generated.
+		return r.ExecBatchInsert(ctx, q, len(rows), args...)
+	}
+
+	var counters []string
+	if isCounter(r.rec) && !opts.DoNothing {
+		counters = append(counters, VersionColumnOf(r.rec))
+	}
+
+	updates := opts.UpdateColumns
+	if opts.DoNothing {
+		updates = nil
+	}
+
+	q += r.Dialect().Upsert(r.rec.TableName(), opts.ConflictKeys, updates, counters)
+	if _, err := r.db.Exec(ctx, r.Dialect().Rebind(q), args...); err != nil {
+		return nil, err
+	}
+
+	// read the ids of the new records.
+	stored, err := r.storedByKeys(WithPrimary(ctx), opts.ConflictKeys, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		if existing, ok := stored[row.key]; ok {
+			ids[i] = PrimaryKeyOf(existing)
+		}
+	}
+
+	return ids, nil
+}
+
+// storedByKeys returns the stored records of the "rows", soft deleted included, by their key.
+func (r *Repository) storedByKeys(ctx context.Context, keys []string, rows []upsertRow) (map[string]interface{}, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	condition := "(" + strings.Join(keys, " = ? AND ") + " = ?)"
+	for _, row := range rows {
+		if row.key == "" {
+			continue
+		}
+
+		conditions = append(conditions, condition)
+		for _, key := range keys {
+			f, _ := fieldOf(row.ptr, key)
+			args = append(args, f.Interface())
+		}
+	}
+
+	stored := make(map[string]interface{})
+	if len(conditions) == 0 {
+		return stored, nil
+	}
+
+	typ := indirectType(reflect.TypeOf(r.rec))
+	dest := reflect.New(reflect.SliceOf(reflect.PtrTo(typ)))
+
+	q := fmt.Sprintf("SELECT * FROM %s WHERE %s", r.rec.TableName(), strings.Join(conditions, " OR "))
+	if err := r.db.Select(ctx, dest.Interface(), r.Dialect().Rebind(q), args...); err != nil && err != ErrNoRows {
+		return nil, err
+	}
+
+	for i := 0; i < dest.Elem().Len(); i++ {
+		rec := dest.Elem().Index(i).Interface()
+		stored[ConflictKeyOf(rec, keys)] = rec
+	}
+
+	return stored, nil
+}
+
+// keyOf returns the values of the "keys" columns of "rec", a pointer to a record, as text.
+func ConflictKeyOf(rec interface{}, keys []string) string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		f, _ := fieldOf(rec, key)
+		v := plainValue(f.Interface())
+		if t, ok := v.(time.Time); ok {
+			v = t.UTC()
+		}
+		values[i] = fmt.Sprintf("%v", v)
+	}
+
+	return strings.Join(values, "\x00")
+}
+
+// ColumnValues returns the values of the "columns" of "rec", a pointer to a record.
+func ColumnValues(rec interface{}, columns []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(columns))
+	for _, column := range columns {
+		if f, ok := fieldOf(rec, column); ok {
+			values[column] = f.Interface()
+		}
+	}
+
+	return values
+}
+
+// CopyColumns sets the "columns" of "dst" to the ones of "src", pointers to records of the same type.
+func CopyColumns(dst, src interface{}, columns []string) {
+	for _, column := range columns {
+		to, okTo := fieldOf(dst, column)
+		from, okFrom := fieldOf(src, column)
+		if okTo && okFrom && to.CanSet() {
+			to.Set(from)
+		}
+	}
+}
+
+// withoutStrings returns a copy of "values" without the "excluded" ones.
+func withoutStrings(values []string, excluded ...string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !containsString(excluded, v) {
+			result = append(result, v)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,263 @@
+package sql
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Versioned can be optionally implemented by a Record
+// to enable optimistic locking on its updates.
+// The version column is either an integer, e.g. "version", which is incremented on each update,
+// or a time column, e.g. "updated_at", which is set to the current time on each update.
+//
+// A non-zero version on the record passed to `Repository.Update`,
+// or the version column on the `Repository.PartialUpdate` attributes,
+// is the version the caller has read: if the stored record
+// has a different version then the update fails with `ErrConflict`.
+type Versioned interface {
+	VersionColumn() string
+}
+
+// ErrConflict is returned by updates of `Versioned` records
+// when the record has changed since it was read.
+var ErrConflict = errors.New("sql: record has been changed")
+
+// VersionColumnOf returns the version column of the "rec" record
+// or an empty string if it does not implement the `Versioned` interface.
+func VersionColumnOf(rec Record) string {
+	if v, ok := rec.(Versioned); ok {
+		return v.VersionColumn()
+	}
+
+	return ""
+}
+
+// VersionOf returns the version of "rec", a pointer to a `Versioned` record,
+// as an int64 or a time.Time. It returns nil and false if the record is not versioned
+// or its version is zero, i.e. it has not been read from the database.
+func VersionOf(rec interface{}) (interface{}, bool) {
+	f, ok := versionField(rec)
+	if !ok {
+		return nil, false
+	}
+
+	if isIntKind(f.Kind()) {
+		if f.Int() == 0 {
+			return nil, false
+		}
+		return f.Int(), true
+	}
+
+	t := timeOf(f.Interface())
+	if t.IsZero() {
+		return nil, false
+	}
+
+	return t, true
+}
+
+// UpdatedAtOf returns the "updated_at" timestamp of "rec", a pointer to a record,
+// and false if the record has no such column or it is not set.
+func UpdatedAtOf(rec interface{}) (time.Time, bool) {
+	f, ok := fieldOf(rec, UpdatedAtColumn)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t := timeOf(f.Interface())
+	return t, !t.IsZero()
+}
+
+// timeOf returns the time of a time column value, the zero time if it is not one or it is not set.
+func timeOf(v interface{}) time.Time {
+	switch v := v.(type) {
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	case time.Time:
+		return v
+	case NullTime:
+		return v.Time
+	}
+
+	return time.Time{}
+}
+
+// CheckVersion returns `ErrConflict` if "expected", a version returned by `VersionOf`,
+// differs from the version of "stored", a pointer to the stored record.
+// A nil "expected" version is never a conflict.
+func CheckVersion(stored interface{}, expected interface{}) error {
+	if expected == nil {
+		return nil
+	}
+
+	current, _ := VersionOf(stored)
+	switch v := current.(type) {
+	case int64:
+		if e, ok := expected.(int64); ok && e == v {
+			return nil
+		}
+	case time.Time:
+		if e, ok := expected.(time.Time); ok && e.Equal(v) {
+			return nil
+		}
+	}
+
+	return ErrConflict
+}
+
+// NextVersion sets the version of "rec", a pointer to a `Versioned` record,
+// to the one that follows the version of "stored":
+// integer versions are incremented and time versions are set to "now".
+func NextVersion(rec interface{}, stored interface{}, now time.Time) {
+	f, ok := versionField(rec)
+	if !ok {
+		return
+	}
+
+	if isIntKind(f.Kind()) {
+		current, _ := VersionOf(stored)
+		n, _ := current.(int64)
+		f.SetInt(n + 1)
+		return
+	}
+
+	Touch(rec, VersionColumnOf(rec.(Record)), now, true)
+}
+
+// SplitVersion returns the version of the "attrs" of a partial update
+// of "rec", converted to its version column's type, and a copy of "attrs" without it.
+// The version is nil if "rec" is not `Versioned` or "attrs" does not contain its column.
+// It returns `ErrUnprocessable` if the version cannot be converted.
+func SplitVersion(rec Record, attrs map[string]interface{}) (interface{}, map[string]interface{}, error) {
+	column := VersionColumnOf(rec)
+	v, ok := attrs[column]
+	if column == "" || !ok {
+		return nil, attrs, nil
+	}
+
+	tmp := reflect.New(indirectType(reflect.TypeOf(rec))).Interface()
+	if err := SetVersion(tmp, v); err != nil {
+		return nil, nil, err
+	}
+	version, _ := VersionOf(tmp)
+
+	rest := make(map[string]interface{}, len(attrs)-1)
+	for k, v := range attrs {
+		if k != column {
+			rest[k] = v
+		}
+	}
+
+	return version, rest, nil
+}
+
+// SetVersion sets the version of "rec", a pointer to a `Versioned` record, to "v".
+// Integer versions accept numbers and numeric strings,
+// time versions accept times and RFC 3339 strings.
+// It returns `ErrUnprocessable` if "v" cannot be converted.
+func SetVersion(rec interface{}, v interface{}) error {
+	f, ok := versionField(rec)
+	if !ok {
+		return ErrUnprocessable
+	}
+
+	if n, ok := v.(json.Number); ok {
+		v = n.String()
+	}
+
+	if isIntKind(f.Kind()) {
+		var n int64
+		switch value := v.(type) {
+		case int:
+			n = int64(value)
+		case int64:
+			n = value
+		case float64:
+			n = int64(value)
+			if float64(n) != value {
+				return ErrUnprocessable
+			}
+		case string:
+			var err error
+			if n, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64); err != nil {
+				return ErrUnprocessable
+			}
+		default:
+			return ErrUnprocessable
+		}
+
+		f.SetInt(n)
+		return nil
+	}
+
+	var t time.Time
+	switch value := v.(type) {
+	case time.Time:
+		t = value
+	case *time.Time:
+		if value == nil {
+			return ErrUnprocessable
+		}
+		t = *value
+	case string:
+		var err error
+		if t, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return ErrUnprocessable
+		}
+	default:
+		return ErrUnprocessable
+	}
+
+	switch f.Interface().(type) {
+	case *time.Time:
+		f.Set(reflect.ValueOf(&t))
+	case time.Time:
+		f.Set(reflect.ValueOf(t))
+	case NullTime:
+		f.Set(reflect.ValueOf(NewNullTime(t)))
+	default:
+		return ErrUnprocessable
+	}
+
+	return nil
+}
+
+func versionField(rec interface{}) (reflect.Value, bool) {
+	r, ok := rec.(Record)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	column := VersionColumnOf(r)
+	if column == "" {
+		return reflect.Value{}, false
+	}
+
+	return fieldOf(rec, column)
+}
+
+// isCounter reports whether the version column of "rec" is an incremented integer.
+func isCounter(rec Record) bool {
+	typ := indirectType(reflect.TypeOf(rec))
+	index, ok := FieldsOf(typ)[VersionColumnOf(rec)]
+	if !ok {
+		return false
+	}
+
+	return isIntKind(typ.FieldByIndex(index).Type.Kind())
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
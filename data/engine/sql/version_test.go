@@ -0,0 +1,97 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+type versionedItem struct {
+	ID      int64  `db:"id"`
+	Name    string `db:"name"`
+	Version int64  `db:"version"`
+}
+
+func (versionedItem) TableName() string     { return "items" }
+func (versionedItem) PrimaryKey() string    { return "id" }
+func (versionedItem) VersionColumn() string { return "version" }
+
+// affected is the `sql.Result` of a write.
+type affected int64
+
+func (n affected) LastInsertId() (int64, error) { return 0, nil }
+func (n affected) RowsAffected() (int64, error) { return int64(n), nil }
+
+// versionDB is a `Database` which holds a single "items" row, if "stored" is not zero,
+// it records the executed writes.
+type versionDB struct {
+	stored versionedItem
+	execs  []string
+}
+
+func (db *versionDB) Get(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	if db.stored.ID == 0 {
+		return ErrNoRows
+	}
+
+	*dest.(*versionedItem) = db.stored
+	return nil
+}
+
+func (db *versionDB) Select(ctx context.Context, dest interface{}, q string, args ...interface{}) error {
+	if db.stored.ID != 0 {
+		*dest.(*int64) = 1
+	}
+	return nil
+}
+
+func (db *versionDB) Exec(ctx context.Context, q string, args ...interface{}) (sql.Result, error) {
+	db.execs = append(db.execs, q)
+
+	id, version := args[len(args)-2], args[len(args)-1]
+	if db.stored.ID == 0 || id != db.stored.ID || version != db.stored.Version {
+		return affected(0), nil
+	}
+
+	db.stored.Version++
+	return affected(1), nil
+}
+
+func TestUpdateVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   versionedItem
+		version  int64
+		expected int
+		err      error
+	}{
+		{"same version", versionedItem{ID: 1, Version: 2}, 2, 1, nil},
+		{"changed", versionedItem{ID: 1, Version: 3}, 2, 0, ErrConflict},
+		{"missing", versionedItem{}, 2, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &versionDB{stored: tt.stored}
+			repo := NewRepository(db, new(versionedItem))
+
+			rec := versionedItem{ID: 1, Name: "a", Version: tt.version}
+			n, err := repo.Update(context.Background(), &rec, "name")
+			if err != tt.err {
+				t.Fatalf("expected error %v but got %v", tt.err, err)
+			}
+			if n != tt.expected {
+				t.Fatalf("expected %d affected but got %d", tt.expected, n)
+			}
+
+			expectedQuery := "UPDATE items SET name = ?, version = version + 1 WHERE id = ? AND version = ?;"
+			if len(db.execs) != 1 || db.execs[0] != expectedQuery {
+				t.Fatalf("expected query %q but got %q", expectedQuery, db.execs)
+			}
+
+			if tt.err == nil && n == 1 && rec.Version != tt.version+1 {
+				t.Fatalf("expected the updated record to be re-read with version %d but got %d", tt.version+1, rec.Version)
+			}
+		})
+	}
+}
@@ -0,0 +1,382 @@
+// Package migrations applies the versioned SQL schema files to the database.
+//
+// Each migration is a pair of files inside the migrations directory:
+// 0001_create_users.up.sql and 0001_create_users.down.sql.
+// Applied versions are tracked on the `schema_migrations` table.
+//
+// Migrations are not atomic: MySQL commits each DDL statement implicitly,
+// so the statements of a migration are executed one by one and a failure
+// leaves the statements before it applied, without a version recorded.
+// Write the statements idempotent (e.g. CREATE TABLE IF NOT EXISTS)
+// so a fixed migration can be run again.
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"morshed/data/engine/sql"
+)
+
+// DefaultDir is the default directory of the SQL files, relative to the working directory.
+var DefaultDir = "./data/migrations/sql"
+
+// TableName is the table which keeps the applied versions.
+const TableName = "schema_migrations"
+
+// Migration holds a single schema version.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string // the SQL of the .up.sql file.
+	Down    string // the SQL of the .down.sql file.
+}
+
+// Status holds a migration and when it was applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time // nil when pending.
+}
+
+// Migrator runs the migrations of a directory against a MySQL database.
+type Migrator struct {
+	db  *sql.MySQL
+	dir string
+}
+
+// New returns a new Migrator for the "dir" migrations directory.
+func New(db *sql.MySQL, dir string) *Migrator {
+	if dir == "" {
+		dir = DefaultDir
+	}
+
+	return &Migrator{db: db, dir: dir}
+}
+
+// Load reads the migrations of the "dir", ordered by their version.
+func Load(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		version, name, direction, ok := parseFilename(f.Name())
+		if !ok {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrations: version %d is used by both %q and %q", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(b)
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrations: %04d_%s: missing up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseFilename splits a 0001_name.up.sql filename.
+func parseFilename(filename string) (version int64, name string, direction string, ok bool) {
+	if !strings.HasSuffix(filename, ".sql") {
+		return
+	}
+	filename = strings.TrimSuffix(filename, ".sql")
+
+	switch {
+	case strings.HasSuffix(filename, ".up"):
+		direction = "up"
+	case strings.HasSuffix(filename, ".down"):
+		direction = "down"
+	default:
+		return
+	}
+	filename = strings.TrimSuffix(filename, "."+direction)
+
+	sep := strings.IndexByte(filename, '_')
+	if sep <= 0 {
+		return
+	}
+
+	version, err := strconv.ParseInt(filename[:sep], 10, 64)
+	if err != nil || version <= 0 {
+		return
+	}
+
+	return version, filename[sep+1:], direction, true
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);`, TableName)
+
+	_, err := m.db.Exec(ctx, q)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Conn.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s;", TableName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// Status returns all the migrations of the directory and whether they are applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]Status, 0, len(migrations))
+	for _, mig := range migrations {
+		s := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			s.AppliedAt = &at
+		}
+		status = append(status, s)
+	}
+
+	return status, nil
+}
+
+// Up applies the next "steps" pending migrations in order, all of them if "steps" <= 0.
+// It returns the applied migrations. A failed migration is not rolled back,
+// see the package documentation.
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		err = m.exec(ctx, s.Up)
+		if err == nil {
+			q := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (?,?);", TableName)
+			_, err = m.db.Exec(ctx, q, s.Version, s.Name)
+		}
+		if err != nil {
+			return done, fmt.Errorf("migrations: up %04d_%s: %w", s.Version, s.Name, err)
+		}
+
+		done = append(done, s.Migration)
+	}
+
+	return done, nil
+}
+
+// Down reverts the last "steps" applied migrations in reverse order, all of them if "steps" <= 0.
+// It returns the reverted migrations. A failed migration is not rolled back,
+// see the package documentation.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(status) - 1; i >= 0; i-- {
+		s := status[i]
+		if s.AppliedAt == nil {
+			continue
+		}
+		if steps > 0 && len(done) == steps {
+			break
+		}
+
+		err = m.exec(ctx, s.Down)
+		if err == nil {
+			q := fmt.Sprintf("DELETE FROM %s WHERE version = ?;", TableName)
+			_, err = m.db.Exec(ctx, q, s.Version)
+		}
+		if err != nil {
+			return done, fmt.Errorf("migrations: down %04d_%s: %w", s.Version, s.Name, err)
+		}
+
+		done = append(done, s.Migration)
+	}
+
+	return done, nil
+}
+
+// exec executes the statements of a migration file one by one.
+// On failure the error reports the failed statement and how many were applied before it.
+func (m *Migrator) exec(ctx context.Context, file string) error {
+	statements := splitStatements(file)
+	for i, stmt := range statements {
+		if _, err := m.db.Exec(ctx, stmt); err != nil {
+			return fmt.Errorf("statement %d of %d (%d applied, not rolled back): %w", i+1, len(statements), i, err)
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits the SQL of a migration file on the semicolons
+// which are not inside quotes or comments. Comments and empty statements are dropped.
+// The client-side DELIMITER command is not supported.
+func splitStatements(file string) (statements []string) {
+	var (
+		b     strings.Builder
+		quote byte // the open quote character, if any.
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(b.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(file); i++ {
+		c := file[i]
+
+		switch {
+		case quote != 0:
+			b.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(file) {
+				i++
+				b.WriteByte(file[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			b.WriteByte(c)
+		case c == '#' || isDashComment(file[i:]):
+			if end := strings.IndexByte(file[i:], '\n'); end >= 0 {
+				i += end
+				b.WriteByte('\n')
+			} else {
+				i = len(file)
+			}
+		case c == '/' && strings.HasPrefix(file[i:], "/*"):
+			if end := strings.Index(file[i+2:], "*/"); end >= 0 {
+				i += end + 3
+				b.WriteByte(' ')
+			} else {
+				i = len(file)
+			}
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return
+}
+
+// isDashComment reports whether "s" starts with a "-- " comment,
+// MySQL requires a whitespace or control character after the dashes.
+func isDashComment(s string) bool {
+	return strings.HasPrefix(s, "--") && (len(s) == 2 || s[2] <= ' ')
+}
+
+// Create writes a new, empty, pair of migration files to the "dir"
+// using the next available version and returns their paths.
+func Create(dir, name string) (up string, down string, err error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if name == "" {
+		return "", "", fmt.Errorf("migrations: name is required")
+	}
+
+	migrations, err := Load(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", "", err
+	}
+
+	var version int64 = 1
+	if n := len(migrations); n > 0 {
+		version = migrations[n-1].Version + 1
+	}
+
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", "", err
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	up, down = base+".up.sql", base+".down.sql"
+
+	if err = ioutil.WriteFile(up, []byte("-- "+name+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+	if err = ioutil.WriteFile(down, []byte("-- revert "+name+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+
+	return up, down, nil
+}
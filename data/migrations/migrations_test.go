@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected []string
+	}{
+		{"empty", "-- revert name\n", nil},
+		{"single", "CREATE TABLE a (id BIGINT);\n", []string{"CREATE TABLE a (id BIGINT)"}},
+		{"many", "ALTER TABLE a ADD b INT;\nALTER TABLE a ADD c INT;", []string{"ALTER TABLE a ADD b INT", "ALTER TABLE a ADD c INT"}},
+		{"no trailing semicolon", "DROP TABLE a", []string{"DROP TABLE a"}},
+		{"comments", "-- a; b\n# c; d\n/* e; f */DROP TABLE a;--\n", []string{"DROP TABLE a"}},
+		{"double dash without space", "SELECT 1--1;", []string{"SELECT 1--1"}},
+		{"quoted semicolons", "INSERT INTO a VALUES ('x;y', \"z;\", 'it\\'s;');SELECT `a;b` FROM a;",
+			[]string{"INSERT INTO a VALUES ('x;y', \"z;\", 'it\\'s;')", "SELECT `a;b` FROM a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitStatements(tt.file); !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("expected %q but got %q", tt.expected, got)
+			}
+		})
+	}
+}
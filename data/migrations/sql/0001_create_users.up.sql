@@ -0,0 +1,14 @@
+CREATE TABLE users (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	firstname VARCHAR(255) NOT NULL,
+	username VARCHAR(255) NOT NULL,
+	dob VARCHAR(32) NOT NULL DEFAULT '',
+	address VARCHAR(255) NOT NULL DEFAULT '',
+	description VARCHAR(1024) NOT NULL DEFAULT '',
+	hashpass VARBINARY(255) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	UNIQUE KEY users_username_uindex (username),
+	KEY users_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
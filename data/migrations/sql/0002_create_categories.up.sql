@@ -0,0 +1,14 @@
+CREATE TABLE categories (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	parent_id BIGINT NOT NULL DEFAULT 0,
+	name_en VARCHAR(255) NOT NULL,
+	name_ar VARCHAR(255) NOT NULL,
+	image_url VARCHAR(2048) NOT NULL,
+	description_en TEXT NOT NULL,
+	description_ar TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY categories_parent_id_index (parent_id),
+	KEY categories_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
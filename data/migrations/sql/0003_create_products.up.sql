@@ -0,0 +1,13 @@
+CREATE TABLE products (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	category_id BIGINT NOT NULL,
+	title VARCHAR(255) NOT NULL,
+	image_url VARCHAR(2048) NOT NULL,
+	price DECIMAL(10, 2) NOT NULL,
+	description TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY products_category_id_index (category_id),
+	KEY products_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
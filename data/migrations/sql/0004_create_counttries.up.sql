@@ -0,0 +1,10 @@
+CREATE TABLE counttries (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	name_en VARCHAR(255) NOT NULL,
+	name_ar VARCHAR(255) NOT NULL,
+	image_url VARCHAR(2048) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY counttries_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,20 @@
+CREATE TABLE destinations (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	category_id BIGINT NOT NULL,
+	name_en VARCHAR(255) NOT NULL,
+	name_ar VARCHAR(255) NOT NULL,
+	cat_en VARCHAR(255) NOT NULL,
+	cat_ar VARCHAR(255) NOT NULL,
+	images_urls JSON NOT NULL,
+	description_en TEXT NOT NULL,
+	description_ar TEXT NOT NULL,
+	address_en VARCHAR(512) NOT NULL,
+	address_ar VARCHAR(512) NOT NULL,
+	latitude DOUBLE NOT NULL,
+	longitude DOUBLE NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY destinations_category_id_index (category_id),
+	KEY destinations_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,20 @@
+CREATE TABLE transportations (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	category_id BIGINT NOT NULL,
+	name_en VARCHAR(255) NOT NULL,
+	name_ar VARCHAR(255) NOT NULL,
+	cat_en VARCHAR(255) NOT NULL,
+	cat_ar VARCHAR(255) NOT NULL,
+	images_urls JSON NOT NULL,
+	description_en TEXT NOT NULL,
+	description_ar TEXT NOT NULL,
+	is_station TINYINT(1) NOT NULL DEFAULT 0,
+	station_id BIGINT NOT NULL DEFAULT 0,
+	ticket_price DECIMAL(10, 2) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY transportations_category_id_index (category_id),
+	KEY transportations_station_id_index (station_id),
+	KEY transportations_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,15 @@
+CREATE TABLE routes (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	trans_id BIGINT NOT NULL,
+	dest_lat DOUBLE NOT NULL,
+	dest_long DOUBLE NOT NULL,
+	eta FLOAT NOT NULL,
+	price DECIMAL(10, 2) NOT NULL,
+	description_en TEXT NOT NULL,
+	description_ar TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY routes_trans_id_index (trans_id),
+	KEY routes_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE destratings (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	user_id BIGINT NOT NULL,
+	dest_id BIGINT NOT NULL,
+	rate TINYINT NOT NULL,
+	comment TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY destratings_user_id_index (user_id),
+	KEY destratings_dest_id_index (dest_id),
+	KEY destratings_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,13 @@
+CREATE TABLE transratings (
+	id BIGINT NOT NULL AUTO_INCREMENT,
+	user_id BIGINT NOT NULL,
+	trans_id BIGINT NOT NULL,
+	rate TINYINT NOT NULL,
+	comment TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	PRIMARY KEY (id),
+	KEY transratings_user_id_index (user_id),
+	KEY transratings_trans_id_index (trans_id),
+	KEY transratings_updated_at_index (updated_at, id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_unicode_ci;
@@ -0,0 +1,11 @@
+UPDATE transportations SET station_id = 0 WHERE station_id IS NULL;
+ALTER TABLE transportations MODIFY station_id BIGINT NOT NULL DEFAULT 0;
+
+UPDATE categories SET parent_id = 0 WHERE parent_id IS NULL;
+ALTER TABLE categories MODIFY parent_id BIGINT NOT NULL DEFAULT 0;
+
+UPDATE users SET dob = '' WHERE dob IS NULL;
+UPDATE users SET description = '' WHERE description IS NULL;
+ALTER TABLE users
+	MODIFY dob VARCHAR(32) NOT NULL DEFAULT '',
+	MODIFY description VARCHAR(1024) NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+ALTER TABLE users
+	MODIFY dob VARCHAR(32) NULL DEFAULT NULL,
+	MODIFY description VARCHAR(1024) NULL DEFAULT NULL;
+
+ALTER TABLE categories MODIFY parent_id BIGINT NULL DEFAULT NULL;
+UPDATE categories SET parent_id = NULL WHERE parent_id = 0;
+
+ALTER TABLE transportations MODIFY station_id BIGINT NULL DEFAULT NULL;
+UPDATE transportations SET station_id = NULL WHERE station_id = 0;
@@ -0,0 +1,43 @@
+ALTER TABLE users
+	DROP KEY users_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE categories
+	DROP KEY categories_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE products
+	DROP KEY products_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE counttries
+	DROP KEY counttries_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE governorates
+	DROP KEY governorates_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE destinations
+	DROP KEY destinations_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE stations
+	DROP KEY stations_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE transportations
+	DROP KEY transportations_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE routes
+	DROP KEY routes_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE destratings
+	DROP KEY destratings_deleted_at_index,
+	DROP COLUMN deleted_at;
+
+ALTER TABLE transratings
+	DROP KEY transratings_deleted_at_index,
+	DROP COLUMN deleted_at;
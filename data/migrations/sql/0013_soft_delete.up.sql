@@ -0,0 +1,43 @@
+ALTER TABLE users
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY users_deleted_at_index (deleted_at);
+
+ALTER TABLE categories
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY categories_deleted_at_index (deleted_at);
+
+ALTER TABLE products
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY products_deleted_at_index (deleted_at);
+
+ALTER TABLE counttries
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY counttries_deleted_at_index (deleted_at);
+
+ALTER TABLE governorates
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY governorates_deleted_at_index (deleted_at);
+
+ALTER TABLE destinations
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY destinations_deleted_at_index (deleted_at);
+
+ALTER TABLE stations
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY stations_deleted_at_index (deleted_at);
+
+ALTER TABLE transportations
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY transportations_deleted_at_index (deleted_at);
+
+ALTER TABLE routes
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY routes_deleted_at_index (deleted_at);
+
+ALTER TABLE destratings
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY destratings_deleted_at_index (deleted_at);
+
+ALTER TABLE transratings
+	ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL,
+	ADD KEY transratings_deleted_at_index (deleted_at);
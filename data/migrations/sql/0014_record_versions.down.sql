@@ -0,0 +1,6 @@
+ALTER TABLE users
+	MODIFY updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+ALTER TABLE destinations DROP COLUMN version;
+
+ALTER TABLE products DROP COLUMN version;
@@ -0,0 +1,6 @@
+ALTER TABLE products ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE destinations ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE users
+	MODIFY updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6);
@@ -1,20 +1,25 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type Category struct {
-	ID            int64      `db:"id" json:"id"`
-	ParentID      int64      `db:"parent_id" json:"parent_id"`
-	NameEn        string     `db:"name_en" json:"name_en"`
-	NameAr        string     `db:"name_ar" json:"name_ar"`
-	ImageURL      string     `db:"image_url" json:"image_url"`
-	DescriptionEn string     `db:"description_en" json:"description_en"`
-	DescriptionAr string     `db:"description_ar" json:"description_ar"`
-	CreatedAt     *time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt     *time.Time `db:"updated_at" json:"updated_at"`
+	ID            int64         `db:"id" json:"id"`
+	ParentID      sql.NullInt64 `db:"parent_id" json:"parent_id"`
+	NameEn        string        `db:"name_en" json:"name_en"`
+	NameAr        string        `db:"name_ar" json:"name_ar"`
+	ImageURL      string        `db:"image_url" json:"image_url"`
+	DescriptionEn string        `db:"description_en" json:"description_en"`
+	DescriptionAr string        `db:"description_ar" json:"description_ar"`
+	CreatedAt     *time.Time    `db:"created_at" json:"created_at"`
+	UpdatedAt     *time.Time    `db:"updated_at" json:"updated_at"`
+	DeletedAt     *time.Time    `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	Parent *Category `db:"-" json:"parent,omitempty"`
 }
 
 func (ct Category) TableName() string {
@@ -29,33 +34,18 @@ func (ct *Category) SortBy() string {
 	return "updated_at"
 }
 
-func (ct *Category) ValidateInsert() bool {
-	return ct.ParentID > 0 && ct.NameEn != "" && ct.NameAr != "" && ct.ImageURL != "" && ct.DescriptionEn != "" && ct.DescriptionAr != ""
-}
-
-func (ct *Category) Scan(rows *sql.Rows) error {
-	ct.CreatedAt = new(time.Time)
-	ct.UpdatedAt = new(time.Time)
-	return rows.Scan(&ct.ID, &ct.ParentID, &ct.NameEn, &ct.NameAr, &ct.ImageURL, &ct.DescriptionEn, &ct.DescriptionAr, &ct.CreatedAt, &ct.UpdatedAt)
+func (ct *Category) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type Categories []*Category
-
-func (cts *Categories) Scan(rows *sql.Rows) (err error) {
-	cct := *cts
-	for rows.Next() {
-		ct := new(Category)
-		if err = ct.Scan(rows); err != nil {
-			return
-		}
-		cct = append(cct, ct)
+func (ct *Category) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("parent", new(Category), "parent_id", "Parent"),
 	}
+}
 
-	if len(cct) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*cts = cct
-
-	return rows.Err()
+func (ct *Category) ValidateInsert() bool {
+	return (!ct.ParentID.Valid || ct.ParentID.Int64 > 0) && ct.NameEn != "" && ct.NameAr != "" && ct.ImageURL != "" && ct.DescriptionEn != "" && ct.DescriptionAr != ""
 }
+
+type Categories []*Category
@@ -1,8 +1,9 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type Country struct {
@@ -12,6 +13,10 @@ type Country struct {
 	ImageURL  string     `db:"image_url" json:"image_url"`
 	CreatedAt *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	Governorates []*Governorate `db:"-" json:"governorates,omitempty"`
 }
 
 func (c Country) TableName() string {
@@ -26,33 +31,18 @@ func (c *Country) SortBy() string {
 	return "updated_at"
 }
 
-func (c *Country) ValidateInsert() bool {
-	return c.NameEn != "" && c.NameAr != "" && c.ImageURL != ""
-}
-
-func (c *Country) Scan(rows *sql.Rows) error {
-	c.CreatedAt = new(time.Time)
-	c.UpdatedAt = new(time.Time)
-	return rows.Scan(&c.ID, &c.NameEn, &c.NameAr, &c.ImageURL, &c.CreatedAt, &c.UpdatedAt)
+func (c *Country) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type Countries []*Country
-
-func (cs *Countries) Scan(rows *sql.Rows) (err error) {
-	cc := *cs
-	for rows.Next() {
-		c := new(Country)
-		if err = c.Scan(rows); err != nil {
-			return
-		}
-		cc = append(cc, c)
+func (c *Country) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.HasMany("governorates", new(Governorate), "country_id", "Governorates"),
 	}
+}
 
-	if len(cc) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*cs = cc
-
-	return rows.Err()
+func (c *Country) ValidateInsert() bool {
+	return c.NameEn != "" && c.NameAr != "" && c.ImageURL != ""
 }
+
+type Countries []*Country
@@ -1,8 +1,9 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type DestRating struct {
@@ -13,6 +14,11 @@ type DestRating struct {
 	Comment   string     `db:"comment" json:"comment"`
 	CreatedAt *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	User        *User        `db:"-" json:"user,omitempty"`
+	Destination *Destination `db:"-" json:"destination,omitempty"`
 }
 
 func (r DestRating) TableName() string {
@@ -27,33 +33,19 @@ func (r *DestRating) SortBy() string {
 	return "updated_at"
 }
 
-func (r *DestRating) ValidateInsert() bool {
-	return r.UserID > 0 && r.DestID > 0 && r.Rate > 0 && r.Comment != ""
-}
-
-func (r *DestRating) Scan(rows *sql.Rows) error {
-	r.CreatedAt = new(time.Time)
-	r.UpdatedAt = new(time.Time)
-	return rows.Scan(&r.ID, &r.UserID, &r.DestID, &r.Rate, &r.Comment, &r.UpdatedAt)
+func (r *DestRating) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type DestRatings []*DestRating
-
-func (rs *DestRatings) Scan(rows *sql.Rows) (err error) {
-	cr := *rs
-	for rows.Next() {
-		r := new(DestRating)
-		if err = r.Scan(rows); err != nil {
-			return
-		}
-		cr = append(cr, r)
+func (r *DestRating) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("user", new(User), "user_id", "User"),
+		sql.BelongsTo("destination", new(Destination), "dest_id", "Destination"),
 	}
+}
 
-	if len(cr) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*rs = cr
-
-	return rows.Err()
+func (r *DestRating) ValidateInsert() bool {
+	return r.UserID > 0 && r.DestID > 0 && r.Rate > 0 && r.Comment != ""
 }
+
+type DestRatings []*DestRating
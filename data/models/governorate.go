@@ -1,8 +1,9 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type Governorate struct {
@@ -13,6 +14,10 @@ type Governorate struct {
 	ImageURL  string     `db:"image_url" json:"image_url"`
 	CreatedAt *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	Country *Country `db:"-" json:"country,omitempty"`
 }
 
 func (g Governorate) TableName() string {
@@ -27,33 +32,18 @@ func (g *Governorate) SortBy() string {
 	return "updated_at"
 }
 
-func (g *Governorate) ValidateInsert() bool {
-	return g.CountryID > 0 && g.NameEn != "" && g.NameAr != "" && g.ImageURL != ""
-}
-
-func (g *Governorate) Scan(rows *sql.Rows) error {
-	g.CreatedAt = new(time.Time)
-	g.UpdatedAt = new(time.Time)
-	return rows.Scan(&g.ID, &g.CountryID, &g.NameEn, &g.NameAr, &g.ImageURL, &g.CreatedAt, &g.UpdatedAt)
+func (g *Governorate) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type Governorates []*Governorate
-
-func (gs *Governorates) Scan(rows *sql.Rows) (err error) {
-	cg := *gs
-	for rows.Next() {
-		g := new(Governorate)
-		if err = g.Scan(rows); err != nil {
-			return
-		}
-		cg = append(cg, g)
+func (g *Governorate) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("country", new(Country), "country_id", "Country"),
 	}
+}
 
-	if len(cg) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*gs = cg
-
-	return rows.Err()
+func (g *Governorate) ValidateInsert() bool {
+	return g.CountryID > 0 && g.NameEn != "" && g.NameAr != "" && g.ImageURL != ""
 }
+
+type Governorates []*Governorate
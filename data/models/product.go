@@ -1,8 +1,11 @@
 package models
 
 import (
-	"database/sql"
+	"context"
+	"strings"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 // Product represents the products entity.
@@ -14,8 +17,13 @@ type Product struct {
 	ImageURL    string     `db:"image_url" json:"image_url"`
 	Price       float32    `db:"price" json:"price"`
 	Description string     `db:"description" json:"description"`
+	Version     int64      `db:"version" json:"version"`
 	CreatedAt   *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	Category *Category `db:"-" json:"category,omitempty"`
 }
 
 // TableName returns the database table name of a Product.
@@ -34,37 +42,50 @@ func (p *Product) SortBy() string {
 	return "updated_at"
 }
 
+// SoftDeleteColumn returns the column which marks a Product as deleted, see `sql.SoftDeletable`.
+func (p *Product) SoftDeleteColumn() string {
+	return "deleted_at"
+}
+
+// Relations returns the relations of a Product, see `sql.Related`.
+func (p *Product) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("category", new(Category), "category_id", "Category"),
+	}
+}
+
+// VersionColumn returns the column which is incremented on each update of a Product, see `sql.Versioned`.
+func (p *Product) VersionColumn() string {
+	return "version"
+}
+
 // ValidateInsert simple check for empty fields that should be required.
 func (p *Product) ValidateInsert() bool {
 	return p.CategoryID > 0 && p.Title != "" && p.ImageURL != "" && p.Price > 0 /* decimal* */ && p.Description != ""
 }
 
-// Scan binds mysql rows to this Product.
-func (p *Product) Scan(rows *sql.Rows) error {
-	p.CreatedAt = new(time.Time)
-	p.UpdatedAt = new(time.Time)
-	return rows.Scan(&p.ID, &p.CategoryID, &p.Title, &p.ImageURL, &p.Price, &p.Description, &p.CreatedAt, &p.UpdatedAt)
+// BeforeInsert trims the text fields of the Product, see `sql.BeforeInsertHook`.
+func (p *Product) BeforeInsert(ctx context.Context) error {
+	p.normalize()
+	return nil
 }
 
-// Products is a list of products. Implements the `Scannable` interface.
-type Products []*Product
-
-// Scan binds mysql rows to this Categories.
-func (ps *Products) Scan(rows *sql.Rows) (err error) {
-	cp := *ps
-	for rows.Next() {
-		p := new(Product)
-		if err = p.Scan(rows); err != nil {
-			return
-		}
-		cp = append(cp, p)
+// BeforeUpdate trims the text fields of the Product
+// and checks its required fields, see `sql.BeforeUpdateHook`.
+func (p *Product) BeforeUpdate(ctx context.Context) error {
+	p.normalize()
+	if p.ID <= 0 || !p.ValidateInsert() {
+		return sql.ErrUnprocessable
 	}
 
-	if len(cp) == 0 {
-		return sql.ErrNoRows
-	}
+	return nil
+}
 
-	*ps = cp
+func (p *Product) normalize() {
+	p.Title = strings.TrimSpace(p.Title)
+	p.ImageURL = strings.TrimSpace(p.ImageURL)
+	p.Description = strings.TrimSpace(p.Description)
+}
 
-	return rows.Err()
-}
\ No newline at end of file
+// Products is a list of products.
+type Products []*Product
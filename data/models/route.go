@@ -1,8 +1,9 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type Route struct {
@@ -16,6 +17,10 @@ type Route struct {
 	DescriptionAr string     `db:"description_ar" json:"description_ar"`
 	CreatedAt     *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt     *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	Transportation *Transportation `db:"-" json:"transportation,omitempty"`
 }
 
 func (r Route) TableName() string {
@@ -30,33 +35,18 @@ func (r *Route) SortBy() string {
 	return "updated_at"
 }
 
-func (r *Route) ValidateInsert() bool {
-	return r.TransId > 0 && r.DestLat > 0 && r.DestLong > 0 && r.Eta > 0 && r.Price > 0 && r.DescriptionEn != "" && r.DescriptionAr != ""
-}
-
-func (r *Route) Scan(rows *sql.Rows) error {
-	r.CreatedAt = new(time.Time)
-	r.UpdatedAt = new(time.Time)
-	return rows.Scan(&r.ID, &r.TransId, &r.DestLat, &r.DestLong, &r.Eta, &r.Price, &r.DescriptionEn, &r.DescriptionAr, &r.CreatedAt, &r.UpdatedAt)
+func (r *Route) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type Routes []*Route
-
-func (rs *Routes) Scan(rows *sql.Rows) (err error) {
-	cr := *rs
-	for rows.Next() {
-		r := new(Route)
-		if err = r.Scan(rows); err != nil {
-			return
-		}
-		cr = append(cr, r)
+func (r *Route) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("transportation", new(Transportation), "trans_id", "Transportation"),
 	}
+}
 
-	if len(cr) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*rs = cr
-
-	return rows.Err()
+func (r *Route) ValidateInsert() bool {
+	return r.TransId > 0 && r.DestLat > 0 && r.DestLong > 0 && r.Eta > 0 && r.Price > 0 && r.DescriptionEn != "" && r.DescriptionAr != ""
 }
+
+type Routes []*Route
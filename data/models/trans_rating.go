@@ -1,8 +1,9 @@
 package models
 
 import (
-	"database/sql"
 	"time"
+
+	"morshed/data/engine/sql"
 )
 
 type TransRating struct {
@@ -13,6 +14,11 @@ type TransRating struct {
 	Comment   string     `db:"comment" json:"comment"`
 	CreatedAt *time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt *time.Time `db:"updated_at" json:"updated_at"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+
+	// relations, see `Relations`.
+	User           *User           `db:"-" json:"user,omitempty"`
+	Transportation *Transportation `db:"-" json:"transportation,omitempty"`
 }
 
 func (r TransRating) TableName() string {
@@ -27,33 +33,19 @@ func (r *TransRating) SortBy() string {
 	return "updated_at"
 }
 
-func (r *TransRating) ValidateInsert() bool {
-	return r.UserID > 0 && r.TransID > 0 && r.Rate > 0 && r.Comment != ""
-}
-
-func (r *TransRating) Scan(rows *sql.Rows) error {
-	r.CreatedAt = new(time.Time)
-	r.UpdatedAt = new(time.Time)
-	return rows.Scan(&r.ID, &r.UserID, &r.TransID, &r.Rate, &r.Comment, &r.UpdatedAt)
+func (r *TransRating) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-type TransRatings []*TransRating
-
-func (rs *TransRatings) Scan(rows *sql.Rows) (err error) {
-	cr := *rs
-	for rows.Next() {
-		r := new(TransRating)
-		if err = r.Scan(rows); err != nil {
-			return
-		}
-		cr = append(cr, r)
+func (r *TransRating) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.BelongsTo("user", new(User), "user_id", "User"),
+		sql.BelongsTo("transportation", new(Transportation), "trans_id", "Transportation"),
 	}
+}
 
-	if len(cr) == 0 {
-		return sql.ErrNoRows
-	}
-
-	*rs = cr
-
-	return rows.Err()
+func (r *TransRating) ValidateInsert() bool {
+	return r.UserID > 0 && r.TransID > 0 && r.Rate > 0 && r.Comment != ""
 }
+
+type TransRatings []*TransRating
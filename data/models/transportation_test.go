@@ -0,0 +1,45 @@
+package models
+
+import (
+	stdsql "database/sql"
+	"testing"
+
+	"morshed/data/engine/sql"
+)
+
+func TestTransportationValidateInsertStation(t *testing.T) {
+	tests := []struct {
+		name      string
+		isStation bool
+		stationID sql.NullInt64
+		expected  bool
+	}{
+		{"not a station", false, sql.NullInt64{}, true},
+		{"station", true, sql.NewNullInt64(1), true},
+		{"station without id", true, sql.NullInt64{}, false},
+		{"station with a NULL id", true, sql.NullInt64{NullInt64: stdsql.NullInt64{Int64: 1}}, false},
+		{"station with a zero id", true, sql.NullInt64{NullInt64: stdsql.NullInt64{Valid: true}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trans := Transportation{
+				CategoryID:    1,
+				NameEn:        "bus",
+				NameAr:        "bus",
+				CatNameEn:     "transport",
+				CatNameAr:     "transport",
+				ImagesURLs:    sql.StringList{"bus.png"},
+				DescriptionEn: "bus",
+				DescriptionAr: "bus",
+				IsStation:     tt.isStation,
+				StationId:     tt.stationID,
+				TicketPrice:   5,
+			}
+
+			if got := trans.ValidateInsert(); got != tt.expected {
+				t.Fatalf("expected %t but got %t", tt.expected, got)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package models
 
 import (
-	"database/sql"
+	"context"
+	"strings"
 	"time"
 
+	"morshed/data/engine/sql"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -15,15 +18,19 @@ import (
 // of the example, we will use this datamodel
 // as the only one User model in our application.
 type User struct {
-	ID 				int64 			`db:"id" json:"id" form:"id"`
-	Firstname		string			`db:"firstname" json:"firstname" form:"firstname"`
-	Username       	string    		`db:"username" json:"username" form:"username"`
-	Dob       	   	string    		`db:"dob" json:"dob" form:"dob"`
-	Address        	string    		`db:"address" json:"address" form:"address"`
-	Description    	string    		`db:"description" json:"description" form:"description"`
-	HashedPassword 	[]byte    		`db:"hashpass" json:"-" form:"-"`
-	CreatedAt   	*time.Time 		`db:"created_at" json:"created_at" form:"created_at"`
-	UpdatedAt   	*time.Time 		`db:"updated_at" json:"updated_at" form:"updated_at"`
+	ID             int64          `db:"id" json:"id" form:"id"`
+	Firstname      string         `db:"firstname" json:"firstname" form:"firstname"`
+	Username       string         `db:"username" json:"username" form:"username"`
+	Dob            sql.NullString `db:"dob" json:"dob" form:"dob"`
+	Address        string         `db:"address" json:"address" form:"address"`
+	Description    sql.NullString `db:"description" json:"description" form:"description"`
+	HashedPassword []byte         `db:"hashpass" json:"-" form:"-"`
+	CreatedAt      *time.Time     `db:"created_at" json:"created_at" form:"created_at"`
+	UpdatedAt      *time.Time     `db:"updated_at" json:"updated_at" form:"updated_at"`
+	DeletedAt      *time.Time     `db:"deleted_at" json:"deleted_at,omitempty" form:"-"`
+
+	// relations, see `Relations`.
+	Ratings []*DestRating `db:"-" json:"ratings,omitempty" form:"-"`
 }
 
 // TableName returns the database table name of a User.
@@ -42,41 +49,48 @@ func (u *User) SortBy() string {
 	return "updated_at"
 }
 
-// ValidateInsert simple check for empty fields that should be required.
-func (u *User) ValidateInsert() bool {
-	return u.Firstname != ""  && u.Username != ""
+// VersionColumn returns the "updated_at" column, which guards the updates of a User, see `sql.Versioned`.
+func (u *User) VersionColumn() string {
+	return "updated_at"
 }
 
-// Scan binds mysql rows to this User.
-func (u *User) Scan(rows *sql.Rows) error {
-	u.CreatedAt = new(time.Time)
-	u.UpdatedAt = new(time.Time)
-	return rows.Scan(&u.ID, &u.Firstname, &u.Username, &u.CreatedAt, &u.UpdatedAt)
+// SoftDeleteColumn returns the column which marks a User as deleted, see `sql.SoftDeletable`.
+func (u *User) SoftDeleteColumn() string {
+	return "deleted_at"
 }
 
-// Users is a list of products. Implements the `Scannable` interface.
-type Users []*User
-
-// Scan binds mysql rows to this Categories.
-func (us *Users) Scan(rows *sql.Rows) (err error) {
-	cp := *us
-	for rows.Next() {
-		u := new(User)
-		if err = u.Scan(rows); err != nil {
-			return
-		}
-		cp = append(cp, u)
+// Relations returns the relations of a User, see `sql.Related`.
+func (u *User) Relations() []sql.Relation {
+	return []sql.Relation{
+		sql.HasMany("ratings", new(DestRating), "user_id", "Ratings"),
 	}
+}
 
-	if len(cp) == 0 {
-		return sql.ErrNoRows
-	}
+// ValidateInsert simple check for empty fields that should be required.
+func (u *User) ValidateInsert() bool {
+	return u.Firstname != "" && u.Username != ""
+}
+
+// BeforeInsert trims the names of the User, see `sql.BeforeInsertHook`.
+func (u *User) BeforeInsert(ctx context.Context) error {
+	u.normalize()
+	return nil
+}
 
-	*us = cp
+// BeforeUpdate trims the names of the User, see `sql.BeforeUpdateHook`.
+func (u *User) BeforeUpdate(ctx context.Context) error {
+	u.normalize()
+	return nil
+}
 
-	return rows.Err()
+func (u *User) normalize() {
+	u.Firstname = strings.TrimSpace(u.Firstname)
+	u.Username = strings.TrimSpace(u.Username)
 }
 
+// Users is a list of users.
+type Users []*User
+
 // IsValid can do some very very simple "low-level" data validations.
 func (u User) IsValid() bool {
 	return u.ID > 0
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"morshed/data/engine/cache"
+	"morshed/data/engine/sql"
+	"morshed/domain/repositories"
+	"morshed/helpers"
+)
+
+// cachedRepository is a `Repository` which serves the reads of another repository
+// from a cache and invalidates the cache on each of its writes, see `NewCachedRepository`.
+type cachedRepository[T any, R repositories.Record[T]] struct {
+	repositories.Repository[T]
+	cache *cache.Cache
+	// bypass reads through the underline repository,
+	// e.g. the unscoped records are never cached.
+	bypass bool
+	// tx is the transaction of a `Join` repository, its writes are invalidated after the commit.
+	tx sql.Database
+}
+
+// NewCachedRepository returns a repository which serves `Select` and `SelectAll`
+// from the "c" cache of the records of "repo", the other reads go to "repo".
+// Every write through the returned repository, including its `Join`, `Unscoped`
+// and `Preload` copies, evicts the written records and all the cached lists of the table.
+// The writes of a `Join` copy are evicted once the transaction is committed, see `sql.AfterCommit`,
+// so a concurrent read can't cache the records as they were before the commit.
+//
+// The records are cached in their JSON form, so records with fields
+// which are not part of it, e.g. the users' hashed password, should not be cached.
+func NewCachedRepository[T any, R repositories.Record[T]](repo repositories.Repository[T], c *cache.Cache) repositories.Repository[T] {
+	return &cachedRepository[T, R]{Repository: repo, cache: c}
+}
+
+// bypassed returns a copy of the repository on top of "repo" which reads through "repo".
+func (r *cachedRepository[T, R]) bypassed(repo repositories.Repository[T]) repositories.Repository[T] {
+	return &cachedRepository[T, R]{Repository: repo, cache: r.cache, bypass: true, tx: r.tx}
+}
+
+// Join returns a copy of the repository which runs its queries inside "tx",
+// its reads are not cached as they may see uncommitted records
+// and its writes are evicted after "tx" is committed.
+func (r *cachedRepository[T, R]) Join(tx sql.Database) repositories.Repository[T] {
+	return &cachedRepository[T, R]{Repository: r.Repository.Join(tx), cache: r.cache, bypass: true, tx: tx}
+}
+
+// Unscoped returns a copy of the repository which includes the soft deleted records,
+// its reads are not cached.
+func (r *cachedRepository[T, R]) Unscoped() repositories.Repository[T] {
+	return r.bypassed(r.Repository.Unscoped())
+}
+
+// Preload returns a copy of the repository which fetches the "names" relations of the records,
+// its reads are not cached.
+func (r *cachedRepository[T, R]) Preload(names ...string) repositories.Repository[T] {
+	return r.bypassed(r.Repository.Preload(names...))
+}
+
+func (r *cachedRepository[T, R]) Select(ctx context.Context, id int64) (T, error) {
+	if r.bypass {
+		return r.Repository.Select(ctx, id)
+	}
+
+	var (
+		rec T
+		b   []byte
+	)
+	if err := r.cache.GetByID(ctx, strconv.FormatInt(id, 10), &b); err != nil {
+		return rec, err
+	}
+
+	err := json.Unmarshal(b, &rec)
+	return rec, err
+}
+
+func (r *cachedRepository[T, R]) SelectAll(ctx context.Context) ([]T, error) {
+	if r.bypass {
+		return r.Repository.SelectAll(ctx)
+	}
+
+	var b []byte
+	if err := r.cache.List(ctx, "", &b); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	var page repositories.Page[T]
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &page); err != nil {
+			return nil, err
+		}
+	}
+
+	return page.Items, nil
+}
+
+// invalidate evicts the "ids" records and the lists of the table from the cache,
+// after the commit of the transaction of a `Join` repository, which drops them on rollback.
+// The write has already happened, so a failure is only logged
+// and it is not canceled along with the context of the write.
+func (r *cachedRepository[T, R]) invalidate(ids ...int64) {
+	evict := func() {
+		if err := r.cache.Invalidate(context.Background(), ids...); err != nil {
+			helpers.Mdebugf("cache(%s).Invalidate(%v): %v", r.cache.Name(), ids, err)
+		}
+	}
+
+	sql.AfterCommit(r.tx, evict)
+}
+
+func (r *cachedRepository[T, R]) Delete(ctx context.Context, id int64) (int, error) {
+	n, err := r.Repository.Delete(ctx, id)
+	r.invalidate(id)
+	return n, err
+}
+
+func (r *cachedRepository[T, R]) Restore(ctx context.Context, id int64) (int, error) {
+	n, err := r.Repository.Restore(ctx, id)
+	r.invalidate(id)
+	return n, err
+}
+
+func (r *cachedRepository[T, R]) Purge(ctx context.Context, id int64) (int, error) {
+	n, err := r.Repository.Purge(ctx, id)
+	r.invalidate(id)
+	return n, err
+}
+
+func (r *cachedRepository[T, R]) Insert(ctx context.Context, rec T) (T, error) {
+	rec, err := r.Repository.Insert(ctx, rec)
+	r.invalidate()
+	return rec, err
+}
+
+func (r *cachedRepository[T, R]) BatchInsert(ctx context.Context, records []T) ([]int64, error) {
+	ids, err := r.Repository.BatchInsert(ctx, records)
+	r.invalidate()
+	return ids, err
+}
+
+func (r *cachedRepository[T, R]) Upsert(ctx context.Context, records []T, opts sql.UpsertOptions) (sql.UpsertReport, error) {
+	report, err := r.Repository.Upsert(ctx, records, opts)
+
+	var ids []int64
+	for _, res := range report.Results {
+		if res.Status == sql.UpsertUpdated {
+			ids = append(ids, res.ID)
+		}
+	}
+	r.invalidate(ids...)
+
+	return report, err
+}
+
+func (r *cachedRepository[T, R]) Update(ctx context.Context, rec T) (T, error) {
+	stored, err := r.Repository.Update(ctx, rec)
+	r.invalidate(sql.PrimaryKeyOf(R(&rec)))
+	return stored, err
+}
+
+func (r *cachedRepository[T, R]) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error) {
+	n, err := r.Repository.PartialUpdate(ctx, id, attrs)
+	r.invalidate(id)
+	return n, err
+}
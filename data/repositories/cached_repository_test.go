@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"context"
+	stdsql "database/sql"
+	"testing"
+
+	"morshed/data/engine/cache"
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+	"morshed/data/models"
+)
+
+// committerTx is a `sql.Database` transaction which keeps its `sql.AfterCommit` functions.
+type committerTx struct {
+	afterCommit []func()
+}
+
+func (tx *committerTx) Get(context.Context, interface{}, string, ...interface{}) error {
+	return sql.ErrNoRows
+}
+
+func (tx *committerTx) Select(context.Context, interface{}, string, ...interface{}) error {
+	return sql.ErrNoRows
+}
+
+func (tx *committerTx) Exec(context.Context, string, ...interface{}) (stdsql.Result, error) {
+	return nil, nil
+}
+
+func (tx *committerTx) AfterCommit(fn func()) {
+	tx.afterCommit = append(tx.afterCommit, fn)
+}
+
+func (tx *committerTx) commit() {
+	for _, fn := range tx.afterCommit {
+		fn()
+	}
+	tx.afterCommit = nil
+}
+
+func TestCachedRepositoryJoinInvalidatesAfterCommit(t *testing.T) {
+	ctx := context.Background()
+	db := memory.New()
+	repo := NewCachedRepository[models.Product](NewMemoryRepository[models.Product](db),
+		cache.New(db.Table(new(models.Product)), "cached-repository-join-products", 0))
+
+	product, err := repo.Insert(ctx, models.Product{CategoryID: 1, Title: "before", ImageURL: "/img.png", Price: 5, Description: "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := func() string {
+		t.Helper()
+
+		p, err := repo.Select(ctx, product.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Title
+	}
+
+	if got := title(); got != "before" {
+		t.Fatalf("expected title %q but got %q", "before", got)
+	}
+
+	// the memory engine writes right away, the cache keeps the record until the commit.
+	tx := new(committerTx)
+	product.Title = "after"
+	if _, err = repo.Join(tx).Update(ctx, product); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := title(); got != "before" {
+		t.Fatalf("expected the cached title %q before the commit but got %q", "before", got)
+	}
+
+	tx.commit()
+	if got := title(); got != "after" {
+		t.Fatalf("expected title %q after the commit but got %q", "after", got)
+	}
+}
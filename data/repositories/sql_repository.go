@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+
+	"morshed/data/engine/sql"
+	"morshed/domain/repositories"
+)
+
+// sqlRepository is the `Repository` of the T records on a MySQL or Postgres database.
+// The records are written with their `sql.InsertColumnsOf` and `sql.UpdateColumnsOf` columns.
+type sqlRepository[T any, R repositories.Record[T]] struct {
+	repo   *sql.Repository
+	schema map[string]reflect.Kind // see `PartialUpdate`.
+}
+
+// NewSQLRepository returns a new repository of the T records, e.g. models.Product,
+// which stores them on the "db" database:
+//
+//	products := NewSQLRepository[models.Product](db)
+func NewSQLRepository[T any, R repositories.Record[T]](db sql.Database) repositories.Repository[T] {
+	rec := R(new(T))
+	return &sqlRepository[T, R]{repo: sql.NewRepository(db, rec), schema: sql.UpdateSchemaOf(rec)}
+}
+
+func (r *sqlRepository[T, R]) with(repo *sql.Repository) repositories.Repository[T] {
+	return &sqlRepository[T, R]{repo: repo, schema: r.schema}
+}
+
+// Join returns a copy of this repository which runs its queries inside "tx".
+func (r *sqlRepository[T, R]) Join(tx sql.Database) repositories.Repository[T] {
+	return r.with(r.repo.Join(tx))
+}
+
+// Unscoped returns a copy of this repository which includes the soft deleted records.
+func (r *sqlRepository[T, R]) Unscoped() repositories.Repository[T] {
+	return r.with(r.repo.Unscoped())
+}
+
+// Preload returns a copy of this repository which fetches the "names" relations of the records.
+func (r *sqlRepository[T, R]) Preload(names ...string) repositories.Repository[T] {
+	return r.with(r.repo.Preload(names...))
+}
+
+func (r *sqlRepository[T, R]) Count(ctx context.Context) (int64, error) {
+	return r.repo.Count(ctx)
+}
+
+func (r *sqlRepository[T, R]) Select(ctx context.Context, id int64) (T, error) {
+	var rec T
+	err := r.repo.GetByID(ctx, &rec, id)
+	return rec, err
+}
+
+func (r *sqlRepository[T, R]) SelectByAttrs(ctx context.Context, attrs map[string]interface{}) (T, error) {
+	var rec T
+	err := r.repo.GetByAttrs(ctx, &rec, attrs)
+	return rec, err
+}
+
+func (r *sqlRepository[T, R]) SelectAll(ctx context.Context) ([]T, error) {
+	var list []T
+	if err := r.repo.GetAll(ctx, &list); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *sqlRepository[T, R]) List(ctx context.Context, opts sql.ListOptions) (repositories.Page[T], error) {
+	var list []T
+	page, err := r.repo.ListPage(ctx, &list, opts)
+	if err != nil && err != sql.ErrNoRows {
+		return repositories.Page[T]{}, err
+	}
+
+	return repositories.Page[T]{Items: list, NextCursor: page.NextCursor}, nil
+}
+
+func (r *sqlRepository[T, R]) Delete(ctx context.Context, id int64) (int, error) {
+	return r.repo.DeleteByID(ctx, id)
+}
+
+func (r *sqlRepository[T, R]) Restore(ctx context.Context, id int64) (int, error) {
+	return r.repo.Restore(ctx, id)
+}
+
+func (r *sqlRepository[T, R]) Purge(ctx context.Context, id int64) (int, error) {
+	return r.repo.Purge(ctx, id)
+}
+
+// Insert stores a record to the database and returns it with its ID.
+func (r *sqlRepository[T, R]) Insert(ctx context.Context, rec T) (T, error) {
+	var zero T
+	if err := beforeInsert(ctx, R(&rec)); err != nil {
+		return zero, err
+	}
+
+	id, err := r.repo.Insert(ctx, R(&rec))
+	if err != nil {
+		return zero, err
+	}
+
+	// re-read the stored row, including the database defaults.
+	if err = r.repo.GetByID(sql.WithPrimary(ctx), R(&rec), id); err != nil {
+		return rec, err
+	}
+
+	err = sql.AfterInsert(ctx, R(&rec), id)
+	return rec, err
+}
+
+// BatchInsert inserts one or more records at once and returns their generated IDs.
+func (r *sqlRepository[T, R]) BatchInsert(ctx context.Context, records []T) ([]int64, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	records = append([]T(nil), records...)
+	ptrs := make([]interface{}, 0, len(records))
+	for i := range records {
+		// all records should be "valid", we don't skip, we cancel.
+		if err := beforeInsert(ctx, R(&records[i])); err != nil {
+			return nil, err
+		}
+		ptrs = append(ptrs, R(&records[i]))
+	}
+
+	ids, err := r.repo.BatchInsert(ctx, ptrs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if err = sql.AfterInsert(ctx, R(&records[i]), ids[i]); err != nil {
+			return ids, err
+		}
+	}
+
+	return ids, nil
+}
+
+// Upsert inserts the records or updates the stored ones with the same
+// conflict keys, see `sql.Repository.Upsert`.
+func (r *sqlRepository[T, R]) Upsert(ctx context.Context, records []T, opts sql.UpsertOptions) (sql.UpsertReport, error) {
+	return r.repo.Upsert(ctx, interfaces(records), opts)
+}
+
+// Update updates a record based on its primary key and returns the stored record,
+// see `sql.Repository.Update`. A record which is not modified is returned with a zero ID
+// and a missing one results to `sql.ErrNoRows`.
+func (r *sqlRepository[T, R]) Update(ctx context.Context, rec T) (T, error) {
+	var zero T
+	n, err := r.repo.Update(ctx, R(&rec), sql.UpdateColumnsOf(R(&rec))...)
+	if err != nil {
+		return zero, err
+	}
+
+	if n == 0 {
+		// the record is either missing or it has the same values.
+		var stored T
+		if err = r.repo.Unscoped().GetByID(sql.WithPrimary(ctx), R(&stored), sql.PrimaryKeyOf(R(&rec))); err != nil {
+			return zero, err
+		}
+
+		return zero, nil
+	}
+
+	// "rec" is re-read by `sql.Repository.Update`.
+	return rec, nil
+}
+
+// PartialUpdate accepts a key-value map to
+// update the record based on the given "id".
+func (r *sqlRepository[T, R]) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error) {
+	return r.repo.PartialUpdate(ctx, id, r.schema, attrs)
+}
+
+// interfaces returns the "records" as a slice of interface values, e.g. for `sql.Repository.Upsert`.
+func interfaces[T any](records []T) []interface{} {
+	values := make([]interface{}, 0, len(records))
+	for _, rec := range records {
+		values = append(values, rec)
+	}
+
+	return values
+}
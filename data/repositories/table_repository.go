@@ -0,0 +1,375 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"morshed/data/engine/bolt"
+	"morshed/data/engine/memory"
+	"morshed/data/engine/sql"
+	"morshed/domain/repositories"
+)
+
+// recordTable is implemented by the storage engines
+// which keep the records of a single type, i.e. `memory.Table` and `bolt.Table`.
+type recordTable interface {
+	Count(ctx context.Context) (int64, error)
+	GetByID(ctx context.Context, dest interface{}, id int64) error
+	GetByAttrs(ctx context.Context, dest interface{}, attrs map[string]interface{}) error
+	GetAll(ctx context.Context, dest interface{}) error
+	DeleteByID(ctx context.Context, id int64) (int, error)
+	Restore(ctx context.Context, id int64) (int, error)
+	Purge(ctx context.Context, id int64) (int, error)
+	Insert(ctx context.Context, rec interface{}) (int64, error)
+	Update(ctx context.Context, rec interface{}) (int, error)
+	PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error)
+	ListPage(ctx context.Context, dest interface{}, opts sql.ListOptions) (sql.Page, error)
+}
+
+// batchInserter is implemented by tables that can insert many records atomically.
+type batchInserter interface {
+	BatchInsert(ctx context.Context, records []interface{}) ([]int64, error)
+}
+
+// tableRepository is the `Repository` of the T records
+// which keeps them on a `recordTable`.
+type tableRepository[T any, R repositories.Record[T]] struct {
+	recordTable
+	tables   func(rec sql.Record) recordTable // the tables of the related records, see `Preload`.
+	unscoped bool                             // see `Unscoped`.
+	preload  []string                         // see `Preload`.
+}
+
+// NewMemoryRepository returns a new repository which stores the T records,
+// e.g. models.Product, on the in-memory "db".
+func NewMemoryRepository[T any, R repositories.Record[T]](db *memory.DB) repositories.Repository[T] {
+	tables := func(rec sql.Record) recordTable { return db.Table(rec) }
+	return &tableRepository[T, R]{recordTable: tables(R(new(T))), tables: tables}
+}
+
+// NewBoltRepository returns a new repository which stores the T records,
+// e.g. models.Product, on the bolt "db".
+func NewBoltRepository[T any, R repositories.Record[T]](db *bolt.DB) repositories.Repository[T] {
+	tables := func(rec sql.Record) recordTable { return db.Table(rec) }
+	return &tableRepository[T, R]{recordTable: tables(R(new(T))), tables: tables}
+}
+
+// insertValidator is implemented by models that check their required fields before insert.
+type insertValidator interface {
+	ValidateInsert() bool
+}
+
+// beforeInsert calls the `sql.BeforeInsert` hooks of "rec",
+// a pointer to a record, and validates it.
+func beforeInsert(ctx context.Context, rec interface{}) error {
+	if err := sql.BeforeInsert(ctx, rec); err != nil {
+		return err
+	}
+
+	if validator, ok := rec.(insertValidator); ok && !validator.ValidateInsert() {
+		return sql.ErrUnprocessable
+	}
+
+	return nil
+}
+
+// scope returns the context of the table calls of "ctx".
+func (r *tableRepository[T, R]) scope(ctx context.Context) context.Context {
+	if r.unscoped {
+		return sql.WithDeleted(ctx)
+	}
+
+	return ctx
+}
+
+// Join returns the repository itself, the table engines do not join sql transactions.
+func (r *tableRepository[T, R]) Join(tx sql.Database) repositories.Repository[T] {
+	return r
+}
+
+// Unscoped returns a copy of the repository which includes the soft deleted records.
+func (r *tableRepository[T, R]) Unscoped() repositories.Repository[T] {
+	return &tableRepository[T, R]{recordTable: r.recordTable, tables: r.tables, unscoped: true, preload: r.preload}
+}
+
+// Preload returns a copy of the repository which fetches the "names" relations of the records.
+func (r *tableRepository[T, R]) Preload(names ...string) repositories.Repository[T] {
+	preload := append(append([]string{}, r.preload...), names...)
+	return &tableRepository[T, R]{recordTable: r.recordTable, tables: r.tables, unscoped: r.unscoped, preload: preload}
+}
+
+// preloadInto fetches the "names" relations of the records of "dest".
+func (r *tableRepository[T, R]) preloadInto(ctx context.Context, dest interface{}, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	return sql.PreloadWith(ctx, R(new(T)), dest, r.loadRelated, names...)
+}
+
+// loadRelated is the `sql.LoadFunc` of the repository,
+// it scans the table of the related records.
+func (r *tableRepository[T, R]) loadRelated(ctx context.Context, of sql.Record, column string, values []interface{}, dest interface{}) error {
+	keys := make(map[string]bool, len(values))
+	for _, v := range values {
+		keys[fmt.Sprintf("%v", v)] = true
+	}
+
+	all := reflect.New(reflect.TypeOf(dest).Elem())
+	if err := r.tables(of).GetAll(ctx, all.Interface()); err != nil {
+		return err
+	}
+
+	list := reflect.ValueOf(dest).Elem()
+	for i := 0; i < all.Elem().Len(); i++ {
+		ptr := all.Elem().Index(i)
+		if keys[sql.ConflictKeyOf(ptr.Interface(), []string{column})] {
+			list.Set(reflect.Append(list, ptr))
+		}
+	}
+
+	return nil
+}
+
+func (r *tableRepository[T, R]) Count(ctx context.Context) (int64, error) {
+	return r.recordTable.Count(r.scope(ctx))
+}
+
+func (r *tableRepository[T, R]) Select(ctx context.Context, id int64) (T, error) {
+	ctx = r.scope(ctx)
+	var rec T
+	err := r.GetByID(ctx, &rec, id)
+	if err == nil {
+		err = r.preloadInto(ctx, &rec, r.preload)
+	}
+
+	return rec, err
+}
+
+func (r *tableRepository[T, R]) SelectByAttrs(ctx context.Context, attrs map[string]interface{}) (T, error) {
+	ctx = r.scope(ctx)
+	var rec T
+	err := r.GetByAttrs(ctx, &rec, attrs)
+	if err == nil {
+		err = r.preloadInto(ctx, &rec, r.preload)
+	}
+
+	return rec, err
+}
+
+func (r *tableRepository[T, R]) SelectAll(ctx context.Context) ([]T, error) {
+	ctx = r.scope(ctx)
+	var list []T
+	if err := r.GetAll(ctx, &list); err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if err := r.preloadInto(ctx, &list, r.preload); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (r *tableRepository[T, R]) List(ctx context.Context, opts sql.ListOptions) (repositories.Page[T], error) {
+	if err := sql.ValidatePreload(R(new(T)), opts.Include...); err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	ctx = r.scope(ctx)
+	var list []T
+	page, err := r.ListPage(ctx, &list, opts)
+	if err != nil && err != sql.ErrNoRows {
+		return repositories.Page[T]{}, err
+	}
+
+	if err = r.preloadInto(ctx, &list, append(append([]string{}, r.preload...), opts.Include...)); err != nil {
+		return repositories.Page[T]{}, err
+	}
+
+	return repositories.Page[T]{Items: list, NextCursor: page.NextCursor}, nil
+}
+
+func (r *tableRepository[T, R]) Delete(ctx context.Context, id int64) (int, error) {
+	ctx = r.scope(ctx)
+	n, err := r.DeleteByID(ctx, id)
+	if err == nil && n > 0 {
+		err = sql.AfterDelete(ctx, R(new(T)), id)
+	}
+
+	return n, err
+}
+
+func (r *tableRepository[T, R]) Restore(ctx context.Context, id int64) (int, error) {
+	return r.recordTable.Restore(r.scope(ctx), id)
+}
+
+func (r *tableRepository[T, R]) Purge(ctx context.Context, id int64) (int, error) {
+	ctx = r.scope(ctx)
+	n, err := r.recordTable.Purge(ctx, id)
+	if err == nil && n > 0 {
+		err = sql.AfterDelete(ctx, R(new(T)), id)
+	}
+
+	return n, err
+}
+
+// Insert stores a record and returns it with its generated ID.
+func (r *tableRepository[T, R]) Insert(ctx context.Context, rec T) (T, error) {
+	var zero T
+	ctx = r.scope(ctx)
+	if err := beforeInsert(ctx, R(&rec)); err != nil {
+		return zero, err
+	}
+
+	id, err := r.recordTable.Insert(ctx, rec)
+	if err != nil {
+		return zero, err
+	}
+
+	var stored T
+	if err = r.GetByID(ctx, &stored, id); err != nil {
+		return stored, err
+	}
+
+	err = sql.AfterInsert(ctx, R(&stored), id)
+	return stored, err
+}
+
+// BatchInsert inserts one or more records at once and returns their generated IDs.
+func (r *tableRepository[T, R]) BatchInsert(ctx context.Context, records []T) ([]int64, error) {
+	ctx = r.scope(ctx)
+	records = append([]T(nil), records...)
+	for i := range records {
+		// all records should be "valid", we don't skip, we cancel.
+		if err := beforeInsert(ctx, R(&records[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	var ids []int64
+	if b, ok := r.recordTable.(batchInserter); ok {
+		var err error
+		if ids, err = b.BatchInsert(ctx, interfaces(records)); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, rec := range records {
+			id, err := r.recordTable.Insert(ctx, rec)
+			if err != nil {
+				return ids, err
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	for i, id := range ids {
+		if err := sql.AfterInsert(ctx, R(&records[i]), id); err != nil {
+			return ids, err
+		}
+	}
+
+	return ids, nil
+}
+
+// Upsert inserts the records or updates the stored ones with the same conflict keys,
+// see `sql.Repository.Upsert`. The records are written one by one.
+func (r *tableRepository[T, R]) Upsert(ctx context.Context, records []T, opts sql.UpsertOptions) (sql.UpsertReport, error) {
+	report := sql.NewUpsertReport(len(records))
+
+	rec := R(new(T))
+	opts, err := sql.ResolveUpsert(rec, opts)
+	if err != nil {
+		return report, err
+	}
+
+	ctx = r.scope(ctx)
+	keyIsPK := len(opts.ConflictKeys) == 1 && opts.ConflictKeys[0] == rec.PrimaryKey()
+	seen := make(map[string]bool)
+	for i, v := range records {
+		if err := beforeInsert(ctx, R(&v)); err != nil {
+			report.Set(i, 0, sql.UpsertFailed, err)
+			continue
+		}
+
+		if keyIsPK && sql.PrimaryKeyOf(R(&v)) == 0 {
+			// a new record, it cannot conflict.
+			r.upsertInsert(ctx, &report, i, v)
+			continue
+		}
+
+		key := sql.ConflictKeyOf(R(&v), opts.ConflictKeys)
+		if seen[key] {
+			report.Set(i, 0, sql.UpsertFailed, sql.ErrDuplicateConflictKey)
+			continue
+		}
+		seen[key] = true
+
+		var stored T
+		err = r.GetByAttrs(sql.WithDeleted(ctx), &stored, sql.ColumnValues(R(&v), opts.ConflictKeys))
+		switch err {
+		case nil:
+			id := sql.PrimaryKeyOf(R(&stored))
+			if opts.DoNothing || len(sql.ChangedColumns(R(&stored), R(&v), opts.UpdateColumns)) == 0 {
+				report.Set(i, id, sql.UpsertSkipped, nil)
+				continue
+			}
+
+			sql.CopyColumns(R(&stored), R(&v), opts.UpdateColumns)
+			if _, err = r.recordTable.Update(ctx, stored); err != nil {
+				report.Set(i, id, sql.UpsertFailed, err)
+				continue
+			}
+			report.Set(i, id, sql.UpsertUpdated, nil)
+		case sql.ErrNoRows:
+			r.upsertInsert(ctx, &report, i, v)
+		default:
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// upsertInsert inserts the "rec" record at "index" of an `Upsert` batch and sets its result.
+func (r *tableRepository[T, R]) upsertInsert(ctx context.Context, report *sql.UpsertReport, index int, rec T) {
+	id, err := r.recordTable.Insert(ctx, rec)
+	if err == nil {
+		err = sql.AfterInsert(ctx, R(&rec), id)
+	}
+
+	if err != nil {
+		report.Set(index, id, sql.UpsertFailed, err)
+		return
+	}
+	report.Set(index, id, sql.UpsertInserted, nil)
+}
+
+// Update updates a record based on its primary key and returns the stored record,
+// a missing record results to `sql.ErrNoRows`. A `sql.Versioned` record which has changed since it was read results to `sql.ErrConflict`.
+func (r *tableRepository[T, R]) Update(ctx context.Context, rec T) (T, error) {
+	var stored T
+	ctx = r.scope(ctx)
+	if err := sql.BeforeUpdate(ctx, R(&rec)); err != nil {
+		return stored, err
+	}
+
+	n, err := r.recordTable.Update(ctx, rec)
+	if err != nil {
+		return stored, err
+	}
+
+	if n == 0 {
+		// the tables update the stored records even if they have the same values.
+		return stored, sql.ErrNoRows
+	}
+
+	// re-read the stored record, including its new version.
+	err = r.recordTable.GetByID(sql.WithDeleted(ctx), &stored, sql.PrimaryKeyOf(R(&rec)))
+	return stored, err
+}
+
+func (r *tableRepository[T, R]) PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error) {
+	return r.recordTable.PartialUpdate(r.scope(ctx), id, attrs)
+}
@@ -2,9 +2,29 @@
 
 package middleware
 
-import "github.com/kataras/iris/v12/middleware/basicauth"
+import (
+	"crypto/subtle"
 
-// BasicAuth middleware sample.
-var BasicAuth = basicauth.Default(map[string]string{
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/middleware/basicauth"
+)
+
+// admins holds the credentials of the administrators.
+var admins = map[string]string{
 	"admin": "password",
-})
+}
+
+// BasicAuth middleware sample.
+var BasicAuth = basicauth.Default(admins)
+
+// IsAdmin reports whether the request carries the basic authentication
+// credentials of an administrator, without stopping the handlers chain.
+func IsAdmin(ctx iris.Context) bool {
+	username, password, ok := ctx.Request().BasicAuth()
+	if !ok {
+		return false
+	}
+
+	expected, ok := admins[username]
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
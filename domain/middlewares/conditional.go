@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kataras/iris/v12"
+)
+
+// CachePolicy holds the Cache-Control directives of the responses of a resource.
+type CachePolicy struct {
+	// MaxAge is how long a client may use a response without asking again,
+	// zero asks the client to revalidate it on every use (no-cache).
+	MaxAge time.Duration
+	// Private keeps the responses out of shared caches, e.g. proxies and CDNs.
+	Private bool
+	// NoStore forbids any cache to keep the responses, they are never validated either.
+	NoStore bool
+}
+
+// String returns the value of the Cache-Control header of the policy.
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+
+	directives := []string{"public"}
+	if p.Private {
+		directives[0] = "private"
+	}
+
+	if p.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.FormatInt(int64(p.MaxAge/time.Second), 10))
+	} else {
+		directives = append(directives, "no-cache")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// CachePolicies holds the cache policy of each resource by its name, see `Conditional`.
+// Resources which are not listed here use the `DefaultCachePolicy`.
+var CachePolicies = map[string]CachePolicy{
+	// the products change rarely and they are the same for every client.
+	"products": {MaxAge: time.Minute},
+	// the users are for the admins only.
+	"users": {Private: true},
+}
+
+// DefaultCachePolicy is the cache policy of the resources which are not listed on `CachePolicies`.
+var DefaultCachePolicy = CachePolicy{Private: true}
+
+// CachePolicyOf returns the cache policy of the "resource".
+func CachePolicyOf(resource string) CachePolicy {
+	if p, ok := CachePolicies[resource]; ok {
+		return p
+	}
+
+	return DefaultCachePolicy
+}
+
+// Conditional is the middleware of the GET and HEAD requests of the "resource",
+// it sets the Cache-Control header of its `CachePolicyOf` to successful responses and
+// answers the conditional requests with 304 Not Modified when the client's copy is still fresh.
+//
+// The validators are the ETag and Last-Modified headers set by the handlers, e.g. from the
+// version and the "updated_at" column of a record. A response without an ETag,
+// e.g. a list of records, gets a weak one from a hash of its body.
+func Conditional(resource string) iris.Handler {
+	policy := CachePolicyOf(resource)
+
+	return func(ctx iris.Context) {
+		if method := ctx.Method(); method != iris.MethodGet && method != iris.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		ctx.Record()
+		ctx.Next()
+
+		if ctx.GetStatusCode() != iris.StatusOK {
+			return
+		}
+
+		ctx.Header("Cache-Control", policy.String())
+		if !policy.Private && !policy.NoStore {
+			// e.g. the admins' ?include_deleted=true responses differ from the other clients' ones.
+			ctx.Header("Vary", "Authorization")
+		}
+		if policy.NoStore {
+			ctx.ResponseWriter().Header().Del("ETag")
+			ctx.ResponseWriter().Header().Del("Last-Modified")
+			return
+		}
+
+		header := ctx.ResponseWriter().Header()
+		etag := header.Get("ETag")
+		if etag == "" {
+			if body := ctx.Recorder().Body(); len(body) > 0 {
+				sum := sha256.Sum256(body)
+				etag = `W/"` + hex.EncodeToString(sum[:16]) + `"`
+				ctx.Header("ETag", etag)
+			}
+		}
+
+		if notModified(ctx.Request(), etag, header.Get("Last-Modified")) {
+			ctx.Recorder().ResetBody()
+			ctx.WriteNotModified()
+		}
+	}
+}
+
+// notModified reports whether the client's copy of a response with the "etag" and "lastModified"
+// validators is still fresh. If-None-Match takes precedence over If-Modified-Since, see RFC 7232 section 6.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagMatch(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+// etagMatch reports whether any of the entity tags of an If-None-Match header
+// matches the "etag" with the weak comparison, see RFC 7232 section 2.3.2.
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"morshed/data/engine/sql"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/middleware/requestid"
+)
+
+type requestIDKey struct{}
+
+// RequestContext binds the id of the request, see `requestid.New`, to the context of the request.
+// The handlers pass the iris context down to the repositories, so the id reaches
+// their queries even through the contexts derived from it, e.g. by `sql.WithDeleted`, see `RequestID`.
+func RequestContext(ctx iris.Context) {
+	if id := requestid.Get(ctx); id != "" {
+		r := ctx.Request()
+		ctx.ResetRequest(r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	}
+
+	ctx.Next()
+}
+
+// RequestID returns the id of the request which "ctx" is the context of, if any, see `RequestContext`.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ReadPrimaryCookie is the name of the cookie which keeps the reads
+// of a client on the primary database after its writes, see `ReadYourWrites`.
+const ReadPrimaryCookie = "read_primary"
+
+// ReadYourWrites routes the queries of the write requests and the requests which
+// follow a write of the same client within "window" to the primary database, see `sql.WithPrimary`,
+// so a client reads its own writes before they reach the replicas.
+// The "window" should be longer than the replication lag.
+func ReadYourWrites(window time.Duration) iris.Handler {
+	return func(ctx iris.Context) {
+		var write bool
+		switch ctx.Method() {
+		case iris.MethodPost, iris.MethodPut, iris.MethodPatch, iris.MethodDelete:
+			write = true
+		}
+
+		if write || ctx.GetCookie(ReadPrimaryCookie) != "" {
+			r := ctx.Request()
+			ctx.ResetRequest(r.WithContext(sql.WithPrimary(r.Context())))
+		}
+
+		if write {
+			// set before the handler writes the response.
+			ctx.SetCookieKV(ReadPrimaryCookie, "true", iris.CookieExpires(window))
+		}
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"morshed/data/engine/sql"
+
+	"github.com/kataras/iris/v12"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	app := iris.New()
+	app.Use(ReadYourWrites(5 * time.Second))
+
+	var primary bool
+	handler := func(ctx iris.Context) {
+		primary = sql.UsesPrimary(ctx)
+	}
+	app.Get("/", handler)
+	app.Post("/", handler)
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(method string, cookies ...*http.Cookie) *http.Response {
+		req := httptest.NewRequest(method, "/", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	if serve(iris.MethodGet); primary {
+		t.Fatal("expected a read without a previous write to use the replicas")
+	}
+
+	resp := serve(iris.MethodPost)
+	if !primary {
+		t.Fatal("expected a write to use the primary")
+	}
+
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == ReadPrimaryCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.MaxAge != 5 {
+		t.Fatalf("expected the %s cookie of 5 seconds but got %v", ReadPrimaryCookie, cookie)
+	}
+
+	if serve(iris.MethodGet, cookie); !primary {
+		t.Fatal("expected a read after a write to use the primary")
+	}
+}
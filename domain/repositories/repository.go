@@ -1,13 +1,61 @@
 package repositories
 
-type DataRepository interface {
-	Size(int64) (int64, error)
-	Select(int64) (interface{}, error)
-	SelectByAttrs(map[string]interface{}) (interface{}, error)
-	SelectAll() ([]interface{}, error)
-	Delete(int64) (int, error)
-	Insert(interface{}) (interface{}, error)
-	BatchInsert([]interface{}) (int, error)
-	Update(interface{}) (interface{}, error)
-	PartialUpdate(int64, map[string]interface{}) (int, error)
+import (
+	"context"
+
+	"morshed/data/engine/sql"
+)
+
+// Record is the constraint of the records of a `Repository`:
+// a pointer to the T struct, e.g. *models.Product, is a `sql.Record`.
+type Record[T any] interface {
+	*T
+	sql.Record
+}
+
+// Repository is the repository of the T records, e.g. models.Product,
+// the records are passed and returned by value.
+// The "ctx" of each call is the context of its queries, e.g. the iris context of the request,
+// it carries their cancellation, the request id and the `sql.WithPrimary` override.
+type Repository[T any] interface {
+	Count(ctx context.Context) (int64, error)
+	Select(ctx context.Context, id int64) (T, error)
+	SelectByAttrs(ctx context.Context, attrs map[string]interface{}) (T, error)
+	SelectAll(ctx context.Context) ([]T, error)
+	// List returns a page of the records, filtered and sorted by the "opts", see `sql.ListOptions`.
+	List(ctx context.Context, opts sql.ListOptions) (Page[T], error)
+	// Delete removes a record, soft deletable records are marked as deleted instead.
+	Delete(ctx context.Context, id int64) (int, error)
+	// Restore brings back a soft deleted record, see `sql.SoftDeletable`.
+	Restore(ctx context.Context, id int64) (int, error)
+	// Purge removes a record, even if it is soft deletable.
+	Purge(ctx context.Context, id int64) (int, error)
+	// Insert stores a record and returns it as stored, with its generated ID.
+	Insert(ctx context.Context, rec T) (T, error)
+	// BatchInsert returns the generated IDs of the inserted records, in order.
+	BatchInsert(ctx context.Context, records []T) ([]int64, error)
+	// Upsert inserts the records or updates the stored ones with the same conflict keys,
+	// it reports the outcome of each record, see `sql.Repository.Upsert`.
+	Upsert(ctx context.Context, records []T, opts sql.UpsertOptions) (sql.UpsertReport, error)
+	// Update updates a record based on its primary key and returns it as stored.
+	// It returns `sql.ErrNoRows` if the record does not exist and
+	// a record with a zero ID if the stored record has not been modified.
+	Update(ctx context.Context, rec T) (T, error)
+	PartialUpdate(ctx context.Context, id int64, attrs map[string]interface{}) (int, error)
+	// Join returns a copy of the repository which runs
+	// its queries inside the "tx" transaction, see `sql.MySQL.WithTx`.
+	Join(tx sql.Database) Repository[T]
+	// Unscoped returns a copy of the repository which
+	// includes the soft deleted records on its queries.
+	Unscoped() Repository[T]
+	// Preload returns a copy of the repository which fetches
+	// the "names" relations of the records it reads, see `sql.Related`.
+	Preload(names ...string) Repository[T]
+}
+
+// Page is a page of the records of `Repository.List`.
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// NextCursor is the ?cursor= of the next page, empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
@@ -1,10 +1,11 @@
 package services
 
 import (
+	"context"
+
+	"morshed/data/engine/sql"
 	"morshed/data/models"
 	repo "morshed/domain/repositories"
-
-	"github.com/kataras/iris/v12"
 )
 
 // ProductService handles CRUID operations of a product datamodel,
@@ -14,72 +15,98 @@ import (
 // It's an interface and it's used as interface everywhere
 // because we may need to change or try an experimental different domain logic at the future.
 type ProductService interface {
-	Count(int64) (int64, error)
-	GetByID(int64) (models.Product, error)
-	GetByAttrs(map[string]interface{}) (models.Product, error)
-	GetAll() ([]models.Product, error)
-	DeleteByID(int64) (int, error)
-	Create(models.Product) (models.Product, error)
-	InsertAll([]interface{}) (int, error)
-	Update(models.Product) (models.Product, error)
-	PatchUpdate(int64, map[string]interface{}) (int, error)
+	Count(context.Context, int64) (int64, error)
+	GetByID(context.Context, int64) (models.Product, error)
+	GetByAttrs(context.Context, map[string]interface{}) (models.Product, error)
+	GetAll(context.Context) ([]models.Product, error)
+	// List returns a page of the products, filtered, sorted and paginated by the "opts",
+	// e.g. of `sql.ParseListOptions`.
+	List(context.Context, sql.ListOptions) (repo.Page[models.Product], error)
+	DeleteByID(context.Context, int64) (int, error)
+	Restore(context.Context, int64) (int, error)
+	Purge(context.Context, int64) (int, error)
+	// Unscoped returns a copy of the service which includes the soft deleted records.
+	Unscoped() ProductService
+	// Preload returns a copy of the service which fetches the "names" relations
+	// of the products it reads, e.g. "category", see `sql.Related`.
+	Preload(names ...string) ProductService
+	Create(context.Context, models.Product) (models.Product, error)
+	InsertAll(context.Context, []models.Product) (int, error)
+	Update(context.Context, models.Product) (models.Product, error)
+	PatchUpdate(context.Context, int64, map[string]interface{}) (int, error)
 }
 
 // NewProductService returns the default product service.
-func NewProductService(repo repo.DataRepository) ProductService {
+func NewProductService(repo repo.Repository[models.Product]) ProductService {
 	return &productService{repo: repo}
 }
 
 type productService struct {
-	Ctx  iris.Context
-	repo repo.DataRepository
+	repo repo.Repository[models.Product]
 }
 
-func (s *productService) Count(id int64) (int64, error) {
-	total, err := s.repo.Size(id)
+func (s *productService) Count(ctx context.Context, id int64) (int64, error) {
+	total, err := s.repo.Count(ctx)
 	return total, err
 }
 
-func (s *productService) GetByID(id int64) (models.Product, error) {
-	prod, err := s.repo.Select(id)
-	return prod.(models.Product), err
+func (s *productService) GetByID(ctx context.Context, id int64) (models.Product, error) {
+	prod, err := s.repo.Select(ctx, id)
+	return prod, err
+}
+
+func (s *productService) GetByAttrs(ctx context.Context, attrs map[string]interface{}) (models.Product, error) {
+	prod, err := s.repo.SelectByAttrs(ctx, attrs)
+	return prod, err
+}
+
+func (s *productService) GetAll(ctx context.Context) ([]models.Product, error) {
+	return s.repo.SelectAll(ctx)
+}
+
+func (s *productService) List(ctx context.Context, opts sql.ListOptions) (repo.Page[models.Product], error) {
+	return s.repo.List(ctx, opts)
 }
 
-func (s *productService) GetByAttrs(attrs map[string]interface{}) (models.Product, error) {
-	prod, err := s.repo.SelectByAttrs(attrs)
-	return prod.(models.Product), err
+func (s *productService) DeleteByID(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Delete(ctx, id)
+	return row, err
 }
 
-func (s *productService) GetAll() ([]models.Product, error) {
-	ps, err := s.repo.SelectAll()
-	var prods []models.Product
-	for _, v := range ps {
-		prods = append(prods, v.(models.Product))
-	}
-	return prods, err
+func (s *productService) Restore(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Restore(ctx, id)
+	return row, err
 }
 
-func (s *productService) DeleteByID(id int64) (int, error) {
-	row, err := s.repo.Delete(id)
+func (s *productService) Purge(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Purge(ctx, id)
 	return row, err
 }
 
-func (s *productService) Create(product models.Product) (models.Product, error) {
-	prod, err := s.repo.Insert(product)
-	return prod.(models.Product), err
+func (s *productService) Unscoped() ProductService {
+	return &productService{repo: s.repo.Unscoped()}
+}
+
+func (s *productService) Preload(names ...string) ProductService {
+	return &productService{repo: s.repo.Preload(names...)}
+}
+
+func (s *productService) Create(ctx context.Context, product models.Product) (models.Product, error) {
+	prod, err := s.repo.Insert(ctx, product)
+	return prod, err
 }
 
-func (s *productService) InsertAll(products []interface{}) (int, error) {
-	len, err := s.repo.BatchInsert(products)
-	return len, err
+func (s *productService) InsertAll(ctx context.Context, products []models.Product) (int, error) {
+	ids, err := s.repo.BatchInsert(ctx, products)
+	return len(ids), err
 }
 
-func (s *productService) Update(product models.Product) (models.Product, error) {
-	prod, err := s.repo.Update(product)
-	return prod.(models.Product), err
+func (s *productService) Update(ctx context.Context, product models.Product) (models.Product, error) {
+	prod, err := s.repo.Update(ctx, product)
+	return prod, err
 }
 
-func (s *productService) PatchUpdate(id int64, attr map[string]interface{}) (int, error) {
-	row, err := s.repo.PartialUpdate(id, attr)
+func (s *productService) PatchUpdate(ctx context.Context, id int64, attr map[string]interface{}) (int, error) {
+	row, err := s.repo.PartialUpdate(ctx, id, attr)
 	return row, err
 }
@@ -1,11 +1,12 @@
 package services
 
 import (
+	"context"
 	"errors"
+
+	"morshed/data/engine/sql"
 	"morshed/data/models"
 	repo "morshed/domain/repositories"
-
-	"github.com/kataras/iris/v12"
 )
 
 // UserService handles CRUID operations of a user datamodel,
@@ -15,78 +16,104 @@ import (
 // It's an interface and it's used as interface everywhere
 // because we may need to change or try an experimental different domain logic at the future.
 type UserService interface {
-	Count(int64) (int64, error)
-	GetByID(int64) (models.User, error)
-	GetByAttrs(map[string]interface{}) (models.User, error)
-	GetAll() ([]models.User, error)
-	DeleteByID(int64) (int, error)
-	Create(models.User) (models.User, error)
-	InsertAll([]interface{}) (int, error)
-	Update(models.User) (models.User, error)
-	PatchUpdate(int64, map[string]interface{}) (int, error)
-	CreateUser(string, models.User) (models.User, error)
+	Count(context.Context, int64) (int64, error)
+	GetByID(context.Context, int64) (models.User, error)
+	GetByAttrs(context.Context, map[string]interface{}) (models.User, error)
+	GetAll(context.Context) ([]models.User, error)
+	// List returns a page of the users, filtered, sorted and paginated by the "opts",
+	// e.g. of `sql.ParseListOptions`.
+	List(context.Context, sql.ListOptions) (repo.Page[models.User], error)
+	DeleteByID(context.Context, int64) (int, error)
+	Restore(context.Context, int64) (int, error)
+	Purge(context.Context, int64) (int, error)
+	// Unscoped returns a copy of the service which includes the soft deleted records.
+	Unscoped() UserService
+	// Preload returns a copy of the service which fetches the "names" relations
+	// of the users it reads, e.g. "ratings", see `sql.Related`.
+	Preload(names ...string) UserService
+	Create(context.Context, models.User) (models.User, error)
+	InsertAll(context.Context, []models.User) (int, error)
+	Update(context.Context, models.User) (models.User, error)
+	PatchUpdate(context.Context, int64, map[string]interface{}) (int, error)
+	CreateUser(context.Context, string, models.User) (models.User, error)
 }
 
 // NewUserService returns the default user service.
-func NewUserService(repo repo.DataRepository) UserService {
+func NewUserService(repo repo.Repository[models.User]) UserService {
 	return &userService{repo: repo}
 }
 
 type userService struct {
-	Ctx  iris.Context
-	repo repo.DataRepository
+	repo repo.Repository[models.User]
 }
 
-func (s *userService) Count(id int64) (int64, error) {
-	total, err := s.repo.Size(id)
+func (s *userService) Count(ctx context.Context, id int64) (int64, error) {
+	total, err := s.repo.Count(ctx)
 	return total, err
 }
 
-func (s *userService) GetByID(id int64) (models.User, error) {
-	user, err := s.repo.Select(id)
-	return user.(models.User), err
+func (s *userService) GetByID(ctx context.Context, id int64) (models.User, error) {
+	user, err := s.repo.Select(ctx, id)
+	return user, err
 }
 
-func (s *userService) GetByAttrs(attrs map[string]interface{}) (models.User, error) {
-	user, err := s.repo.SelectByAttrs(attrs)
-	return user.(models.User), err
+func (s *userService) GetByAttrs(ctx context.Context, attrs map[string]interface{}) (models.User, error) {
+	user, err := s.repo.SelectByAttrs(ctx, attrs)
+	return user, err
 }
 
-func (s *userService) GetAll() ([]models.User, error) {
-	us, err := s.repo.SelectAll()
-	var prods []models.User
-	for _, v := range us {
-		prods = append(prods, v.(models.User))
-	}
-	return prods, err
+func (s *userService) GetAll(ctx context.Context) ([]models.User, error) {
+	return s.repo.SelectAll(ctx)
+}
+
+func (s *userService) List(ctx context.Context, opts sql.ListOptions) (repo.Page[models.User], error) {
+	return s.repo.List(ctx, opts)
+}
+
+func (s *userService) DeleteByID(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Delete(ctx, id)
+	return row, err
 }
 
-func (s *userService) DeleteByID(id int64) (int, error) {
-	row, err := s.repo.Delete(id)
+func (s *userService) Restore(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Restore(ctx, id)
 	return row, err
 }
 
-func (s *userService) Create(user models.User) (models.User, error) {
-	us, err := s.repo.Insert(user)
-	return us.(models.User), err
+func (s *userService) Purge(ctx context.Context, id int64) (int, error) {
+	row, err := s.repo.Purge(ctx, id)
+	return row, err
+}
+
+func (s *userService) Unscoped() UserService {
+	return &userService{repo: s.repo.Unscoped()}
+}
+
+func (s *userService) Preload(names ...string) UserService {
+	return &userService{repo: s.repo.Preload(names...)}
+}
+
+func (s *userService) Create(ctx context.Context, user models.User) (models.User, error) {
+	us, err := s.repo.Insert(ctx, user)
+	return us, err
 }
 
-func (s *userService) InsertAll(users []interface{}) (int, error) {
-	len, err := s.repo.BatchInsert(users)
-	return len, err
+func (s *userService) InsertAll(ctx context.Context, users []models.User) (int, error) {
+	ids, err := s.repo.BatchInsert(ctx, users)
+	return len(ids), err
 }
 
-func (s *userService) Update(user models.User) (models.User, error) {
-	us, err := s.repo.Update(user)
-	return us.(models.User), err
+func (s *userService) Update(ctx context.Context, user models.User) (models.User, error) {
+	us, err := s.repo.Update(ctx, user)
+	return us, err
 }
 
-func (s *userService) PatchUpdate(id int64, attr map[string]interface{}) (int, error) {
-	row, err := s.repo.PartialUpdate(id, attr)
+func (s *userService) PatchUpdate(ctx context.Context, id int64, attr map[string]interface{}) (int, error) {
+	row, err := s.repo.PartialUpdate(ctx, id, attr)
 	return row, err
 }
 
-func (s *userService) CreateUser(userPassword string, user models.User) (models.User, error) {
+func (s *userService) CreateUser(ctx context.Context, userPassword string, user models.User) (models.User, error) {
 	if user.ID > 0 || userPassword == "" || user.Firstname == "" || user.Username == "" {
 		return models.User{}, errors.New("unable to create this user")
 	}
@@ -97,6 +124,6 @@ func (s *userService) CreateUser(userPassword string, user models.User) (models.
 	}
 	user.HashedPassword = hashed
 
-	us, err := s.repo.Insert(user)
-	return us.(models.User), err
+	us, err := s.repo.Insert(ctx, user)
+	return us, err
 }
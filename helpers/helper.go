@@ -3,6 +3,8 @@ package helpers
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/kataras/iris/v12"
 )
@@ -25,6 +27,14 @@ func MwriteEntityNotFound(ctx iris.Context) {
 	ctx.StopWithJSON(iris.StatusNotFound, MnewError(iris.StatusNotFound, ctx.Request().Method, ctx.Path(), "entity does not exist"))
 }
 
+// MwriteCreated sends the persisted "resource" with a 201 status code
+// and a Location header of its URL, the request path followed by the "id".
+func MwriteCreated(ctx iris.Context, id int64, resource interface{}) {
+	ctx.Header("Location", strings.TrimSuffix(ctx.Path(), "/")+"/"+strconv.FormatInt(id, 10))
+	ctx.StatusCode(iris.StatusCreated)
+	ctx.JSON(resource)
+}
+
 func Mgetenv(key string, def string) string {
 	v := os.Getenv(key)
 	if v == "" {
@@ -49,59 +49,76 @@ func main() {
 	app.UseRouter(ac.Handler)
 	app.UseRouter(recover.New())
 
-	/////////////////////////////////////////////////
-	////////////// ADMIN PANEL /////////////////////
-
-	// Initialize Admin Panel
-	eng := engine.Default()
-	if err := eng.AddConfigFromJSON("./goadmin/config.json").
-		AddGenerators(tables.Generators).
-		Use(app); err != nil {
-		panic(err)
-	}
-	eng.HTML("GET", "/karakon", pages.GetDashBoard)
-	eng.HTMLFile("GET", "/karakon/hello", "./goadmin/html/hello.tmpl", map[string]interface{}{
-		"msg": "Hello world",
-	})
-
-	app.HandleDir("/goadmin/uploads", "./goadmin/uploads", iris.DirOptions{
-		IndexName: "/index.html",
-		//Gzip:      false,
-		ShowList: false,
-	})
-
 	/////////////////////////////////////////////////
 	////////////////// DataSource //////////////////
 
 	// Prepare our repositories and services.
-	db, err := datasource.StartMySql(datasource.MySQL)
+	// Set DATASOURCE_ENGINE=memory or DATASOURCE_ENGINE=bolt (BOLT_PATH=./morshed.db)
+	// to run without a database server.
+	dsEngine, err := datasource.EngineFromEnv()
+	if err != nil {
+		app.Logger().Fatalf("error while loading the datasource: %v", err)
+		return
+	}
+
+	src, err := datasource.Open(dsEngine)
 	if err != nil {
 		app.Logger().Fatalf("error while loading the users: %v", err)
 		return
 	}
 
+	/////////////////////////////////////////////////
+	////////////// ADMIN PANEL /////////////////////
+
+	// Initialize Admin Panel, it works on top of the MySQL database only.
+	var eng *engine.Engine
+	if src.Engine == datasource.MySQL {
+		eng = engine.Default()
+		if err := eng.AddConfigFromJSON("./goadmin/config.json").
+			AddGenerators(tables.Generators).
+			Use(app); err != nil {
+			panic(err)
+		}
+		eng.HTML("GET", "/karakon", pages.GetDashBoard)
+		eng.HTMLFile("GET", "/karakon/hello", "./goadmin/html/hello.tmpl", map[string]interface{}{
+			"msg": "Hello world",
+		})
+
+		app.HandleDir("/goadmin/uploads", "./goadmin/uploads", iris.DirOptions{
+			IndexName: "/index.html",
+			//Gzip:      false,
+			ShowList: false,
+		})
+	}
+
 	/////////////////////////////////////////////////
 	/////////////////// Routing ////////////////////
 	///////////////////////////////////////////////
 
 	secret := helpers.Mgetenv("JWT_SECRET", "EbnJO3bwmX")
 
-	authRouter := api.Router(db, secret)
+	authRouter := api.Router(src, secret)
 	app.PartyFunc("/", authRouter)
 
 	/////////////////////////////////////////////////
 	//////////////////// RUN ///////////////////////
 	///////////////////////////////////////////////
 
-	app.Listen(":80", iris.WithOptimizations)
+	// Set ADDR, e.g. ADDR=:8081, to run several instances on the same machine,
+	// see the CACHE_* env variables to share their caches.
+	addr := helpers.Mgetenv("ADDR", ":80")
+	app.Listen(addr, iris.WithOptimizations)
 
 	go func() {
-		_ = app.Run(iris.Addr(":80"))
+		_ = app.Run(iris.Addr(addr))
 	}()
 
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt)
 	<-quit
 	log.Print("closing database connection")
-	eng.MysqlConnection().Close()
+	if eng != nil {
+		eng.MysqlConnection().Close()
+	}
+	src.Close()
 }